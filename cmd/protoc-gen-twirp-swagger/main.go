@@ -1,54 +1,264 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
+	"log/slog"
+	"path"
 
-	"github.com/apex/log"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/go-bridget/twirp-swagger-gen/internal/config"
 	"github.com/go-bridget/twirp-swagger-gen/internal/swagger"
+	"github.com/go-openapi/spec"
 	"google.golang.org/protobuf/compiler/protogen"
 )
 
 var _ = spew.Dump
 
-func init() {
-	log.SetLevel(log.InfoLevel)
+// buildVersion is set at build time with
+// `-ldflags "-X main.buildVersion=1.2.3"`.
+var buildVersion string
+
+// requiredOption returns an error for a plugin option which is needed
+// by another option, with an example of how to pass it.
+func requiredOption(name, requiredBy, example string) error {
+	return fmt.Errorf("plugin option %q is required by %q; pass it as --twirp-swagger_opt=%s=<value>, e.g. %s=%s", name, requiredBy, name, name, example)
 }
 
 func main() {
 	var flags flag.FlagSet
+	configFile := flags.String("config", "", "")
 	hostname := flags.String("hostname", "example.com", "")
 	pathPrefix := flags.String("path_prefix", "/twirp", "")
 	outputSuffix := flags.String("output_suffix", ".swagger.json", "")
+	version := flags.String("version", "", "")
+	title := flags.String("title", "", "")
+	description := flags.String("description", "", "")
+	contactName := flags.String("contact_name", "", "")
+	contactEmail := flags.String("contact_email", "", "")
+	contactURL := flags.String("contact_url", "", "")
+	termsOfService := flags.String("terms_of_service", "", "")
+	noAuth := flags.Bool("no_auth", false, "")
+	var extensions config.Extensions
+	flags.Var(&extensions, "extension", "")
+	var renames config.Renames
+	flags.Var(&renames, "rename_definition", "")
+	authRequired := flags.Bool("auth_required", true, "")
+	versionInPath := flags.Bool("version_in_path", false, "")
+	apiPrefix := flags.String("api_prefix", "", "")
+	visibilityOption := flags.String("visibility_option", "", "")
+	splitByService := flags.Bool("split_by_service", false, "")
+	mergeOutput := flags.Bool("merge_output", false, "")
+	emitSourceInfo := flags.Bool("emit_source_info", false, "")
+	fieldsSuffix := flags.Bool("fields_suffix", true, "")
+	warnDeprecatedServices := flags.Bool("warn_deprecated_services", false, "")
+	lint := flags.Bool("lint", false, "")
+	strict := flags.Bool("strict", false, "")
+	responsesFile := flags.String("responses", "", "")
+	definitionSeparator := flags.String("definition_separator", "_", "")
+	fieldOrder := config.NewChoice("", swagger.FieldOrderDeclaration, swagger.FieldOrderProtoNumber)
+	flags.Var(fieldOrder, "field_order", "")
+	definitionOrder := flags.Bool("definition_order", false, "")
+	autoTitles := flags.Bool("auto_titles", true, "")
+	asyncAPI := flags.Bool("asyncapi", false, "")
+	gatewayOptions := flags.Bool("gateway_options", false, "")
+	schemaRegistryURL := flags.String("schema_registry_url", "", "")
+	generatorInfo := flags.Bool("generator_info", false, "")
+	parallelImports := flags.Int("parallel_imports", 1, "")
+	formatPatterns := flags.Bool("format_patterns", false, "")
+	overlayFile := flags.String("overlay", "", "")
+	overlayArrays := flags.String("overlay_arrays", swagger.OverlayArraysAppend, "")
+	var protoPaths config.StringList
+	flags.Var(&protoPaths, "proto_path", "")
+	var serviceTags config.StringList
+	flags.Var(&serviceTags, "tags", "")
+	inlineEnums := flags.Bool("inline_enums", false, "")
+	generatedBy := flags.Bool("generated_by", false, "")
+	useAllOf := flags.Bool("use_allof", false, "")
+	wrapRefs := flags.Bool("wrap_refs", false, "")
+	var onlyPackages config.StringList
+	flags.Var(&onlyPackages, "only_packages", "")
+	int64AsString := flags.Bool("int64_as_string", true, "")
+	strictObjects := flags.Bool("strict_objects", false, "")
+	skipWellKnown := flags.Bool("skip_well_known", false, "")
+	definitionsOnly := flags.Bool("definitions_only", false, "")
+	paginationTokenField := flags.String("pagination_token_field", "next_page_token", "")
+	reportFile := flags.String("report", "", "")
+	logFormat := flags.String("log_format", "text", "")
+	logLevel := flags.String("log_level", "info", "")
 	opts := protogen.Options{
 		ParamFunc: flags.Set,
 	}
 	opts.Run(func(gen *protogen.Plugin) error {
+		if *configFile != "" {
+			cfg, err := config.Load(*configFile)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Apply(&flags); err != nil {
+				return err
+			}
+		}
+
+		if err := config.SetupLogging(*logFormat, *logLevel); err != nil {
+			return err
+		}
+
+		if *versionInPath && *version == "" {
+			return requiredOption("version", "version_in_path", "1.0.0")
+		}
+
+		var responses map[string]spec.Response
+		if *responsesFile != "" {
+			var err error
+			if responses, err = swagger.LoadResponses(*responsesFile); err != nil {
+				return err
+			}
+		}
+
+		var overlay map[string]interface{}
+		if *overlayFile != "" {
+			var err error
+			if overlay, err = swagger.LoadOverlay(*overlayFile); err != nil {
+				return err
+			}
+		}
+
+		cache := swagger.NewParseCache()
+		report := swagger.NewReport()
+
+		// With merge_output, the documents are combined into one
+		// file, which is written if any of the inputs has services.
+		var (
+			documents   []*spec.Swagger
+			hasServices bool
+		)
 		for _, f := range gen.Files {
 			in := f.Desc.Path()
-			log.Debugf("generating: %q", in)
+			slog.Debug("generating", "file", in)
 
 			if !f.Generate {
-				log.Debugf("skip generating: %q", in)
+				slog.Debug("skip generating", "file", in)
 				continue
 			}
 
-			writer := swagger.NewWriter(in, *hostname, *pathPrefix)
+			writer := swagger.NewWriter(in, *hostname, *pathPrefix,
+				swagger.WithVersion(*version),
+				swagger.WithTitle(*title),
+				swagger.WithDescription(*description),
+				swagger.WithContact(*contactName, *contactEmail, *contactURL),
+				swagger.WithTermsOfService(*termsOfService),
+				swagger.WithExtensions(extensions),
+				swagger.WithRenames(renames),
+				swagger.WithNoAuth(*noAuth),
+				swagger.WithAuthRequired(*authRequired),
+				swagger.WithVersionInPath(*versionInPath),
+				swagger.WithAPIPrefix(*apiPrefix),
+				swagger.WithVisibilityOption(*visibilityOption),
+				swagger.WithSourceInfo(*emitSourceInfo),
+				swagger.WithFieldsSuffix(*fieldsSuffix),
+				swagger.WithWarnDeprecatedServices(*warnDeprecatedServices),
+				swagger.WithLint(*lint),
+				swagger.WithStrict(*strict),
+				swagger.WithResponses(responses),
+				swagger.WithDefinitionSeparator(*definitionSeparator),
+				swagger.WithFieldOrder(fieldOrder.Value),
+				swagger.WithDefinitionOrder(*definitionOrder),
+				swagger.WithAutoTitles(*autoTitles),
+				swagger.WithGatewayOptions(*gatewayOptions),
+				swagger.WithSchemaRegistryURL(*schemaRegistryURL),
+				swagger.WithGeneratorInfo(*generatorInfo),
+				swagger.WithParallelImports(*parallelImports),
+				swagger.WithFormatPatterns(*formatPatterns),
+				swagger.WithOverlay(overlay, *overlayArrays),
+				swagger.WithProtoPaths(protoPaths),
+				swagger.WithServiceTags(serviceTags),
+				swagger.WithInlineEnums(*inlineEnums),
+				swagger.WithGeneratedBy(*generatedBy),
+				swagger.WithAllOf(*useAllOf),
+				swagger.WithWrapRefs(*wrapRefs),
+				swagger.WithOnlyPackages(onlyPackages),
+				swagger.WithInt64AsString(*int64AsString),
+				swagger.WithParseCache(cache),
+				swagger.WithStrictObjects(*strictObjects),
+				swagger.WithGeneratorVersion(buildVersion),
+				swagger.WithSkipWellKnown(*skipWellKnown),
+				swagger.WithDefinitionsOnly(*definitionsOnly),
+				swagger.WithPaginationTokenField(*paginationTokenField),
+				swagger.WithReport(report),
+			)
 			if err := writer.WalkFile(); err != nil {
 				if errors.Is(err, swagger.ErrNoServiceDefinition) {
-					log.Debugf("skip writing file, %s: %q", err, in)
+					if *mergeOutput {
+						documents = append(documents, writer.Swagger)
+					}
+					slog.Debug("skip writing file", "file", in, "reason", err)
 					continue
 				}
 				return err
 			}
 
+			if *asyncAPI && writer.HasStreams() {
+				out := f.GeneratedFilenamePrefix + ".asyncapi.json"
+				g := gen.NewGeneratedFile(out, f.GoImportPath)
+				if _, err := g.Write(writer.GetAsyncAPI()); err != nil {
+					return err
+				}
+			}
+
+			if *mergeOutput {
+				documents = append(documents, writer.Swagger)
+				hasServices = true
+				continue
+			}
+
+			if *splitByService {
+				for _, service := range writer.Services() {
+					out := path.Join(path.Dir(f.GeneratedFilenamePrefix), service+*outputSuffix)
+					g := gen.NewGeneratedFile(out, f.GoImportPath)
+					if _, err := g.Write(writer.GetService(service)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
 			out := f.GeneratedFilenamePrefix + *outputSuffix
 			g := gen.NewGeneratedFile(out, f.GoImportPath)
 			if _, err := g.Write(writer.Get()); err != nil {
 				return err
 			}
 		}
+
+		if *mergeOutput && hasServices {
+			combined, err := swagger.Combine(documents...)
+			if err != nil {
+				return err
+			}
+			if *title == "" {
+				info := *combined.Info
+				info.Title = "api"
+				combined.Info = &info
+			}
+			body, err := json.MarshalIndent(combined, "", "  ")
+			if err != nil {
+				return err
+			}
+			g := gen.NewGeneratedFile("api"+*outputSuffix, "")
+			if _, err := g.Write(body); err != nil {
+				return err
+			}
+		}
+
+		// The report is written next to the generated files.
+		if *reportFile != "" {
+			g := gen.NewGeneratedFile(*reportFile, "")
+			if _, err := g.Write(report.Get()); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 }
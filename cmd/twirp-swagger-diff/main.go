@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/go-bridget/twirp-swagger-gen/internal/diff"
+)
+
+func main() {
+	var (
+		before string
+		after  string
+	)
+	flag.StringVar(&before, "before", "", "Previous swagger.json file")
+	flag.StringVar(&after, "after", "", "Current swagger.json file")
+	flag.Parse()
+
+	if before == "" {
+		fatal("Missing parameter: -before [old.swagger.json]")
+	}
+	if after == "" {
+		fatal("Missing parameter: -after [new.swagger.json]")
+	}
+
+	oldSpec, err := diff.Load(before)
+	if err != nil {
+		fatal("exit with error", "error", err)
+	}
+	newSpec, err := diff.Load(after)
+	if err != nil {
+		fatal("exit with error", "error", err)
+	}
+
+	changes := diff.Compare(oldSpec, newSpec)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if diff.HasBreaking(changes) {
+		os.Exit(exitBreaking)
+	}
+}
+
+// Exit codes, so CI can tell breaking changes from invalid inputs.
+const (
+	exitBreaking = 1
+	exitError    = 2
+)
+
+// fatal logs the message and exits with exitError.
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(exitError)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+// indexEntry lists a generated swagger file in the -index file.
+type indexEntry struct {
+	File    string `json:"file"`
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// writeIndex writes a JSON list of the generated swagger files with
+// their title and version, for documentation portals to discover.
+func writeIndex(filename string, files []string) error {
+	index := make([]indexEntry, 0, len(files))
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		document := &spec.Swagger{}
+		if err := json.Unmarshal(body, document); err != nil {
+			return errors.Wrapf(err, "can't index %s", file)
+		}
+
+		entry := indexEntry{
+			File: file,
+		}
+		if document.Info != nil {
+			entry.Title = document.Info.Title
+			entry.Version = document.Info.Version
+		}
+		index = append(index, entry)
+	}
+
+	body, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, body, os.ModePerm^0111)
+}
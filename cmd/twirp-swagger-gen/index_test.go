@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-bridget/twirp-swagger-gen/internal/config"
+)
+
+func TestWriteIndex(t *testing.T) {
+	dir := t.TempDir()
+	manifest := &config.Manifest{
+		Jobs: []config.Job{
+			{In: "../../internal/swagger/testdata/simple_service.proto", Out: filepath.Join(dir, "simple.swagger.json"), Version: "1.0.0"},
+			{In: "../../internal/swagger/testdata/enums.proto", Out: filepath.Join(dir, "enums.swagger.json")},
+		},
+	}
+
+	for _, parallel := range []bool{false, true} {
+		files, err := parseManifest(manifest, "api.example.com", "/twirp", parallel, outputOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		index := filepath.Join(dir, "index.json")
+		if err := writeIndex(index, files); err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := ioutil.ReadFile(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []indexEntry{}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []indexEntry{
+			{File: filepath.Join(dir, "simple.swagger.json"), Title: "simple_service.proto", Version: "1.0.0"},
+			{File: filepath.Join(dir, "enums.swagger.json"), Title: "enums.proto", Version: "version not set"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parallel=%v: got index %+v, want %+v", parallel, got, want)
+		}
+	}
+}
@@ -2,27 +2,152 @@ package main
 
 import (
 	"flag"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
-	"github.com/apex/log"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/go-bridget/twirp-swagger-gen/internal/config"
 	"github.com/go-bridget/twirp-swagger-gen/internal/swagger"
 	"github.com/pkg/errors"
 )
 
 var _ = spew.Dump
 
-func parse(hostname, filename, output, prefix string) error {
+// buildVersion is set at build time with
+// `-ldflags "-X main.buildVersion=1.2.3"`.
+var buildVersion string
+
+// outputOptions control which files get written for each input.
+type outputOptions struct {
+	splitByService bool
+	asyncAPI       bool
+	merge          bool
+
+	// skip lists inputs which don't produce any files, like the
+	// protoc plugin skips files which are only imported.
+	skip []string
+}
+
+// skipped reports if filename is one of the skipped inputs.
+func (o outputOptions) skipped(filename string) bool {
+	for _, name := range o.skip {
+		if filepath.Clean(name) == filepath.Clean(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse generates the swagger document for filename, and returns the
+// names of the written swagger files.
+func parse(hostname, filename, output, prefix string, outputs outputOptions, opts ...swagger.WriterOption) ([]string, error) {
 	if filename == output {
-		return errors.New("output file must be different than input file")
+		return nil, errors.New("output file must be different than input file")
+	}
+	if outputs.skipped(filename) {
+		slog.Info("skip generating", "file", filename)
+		return nil, nil
 	}
 
-	writer := swagger.NewWriter(filename, hostname, prefix)
+	writer := swagger.NewWriter(filename, hostname, prefix, opts...)
 	if err := writer.WalkFile(); err != nil {
 		if !errors.Is(err, swagger.ErrNoServiceDefinition) {
-			return err
+			return nil, err
+		}
+	}
+	if outputs.asyncAPI && writer.HasStreams() {
+		if err := ioutil.WriteFile(asyncAPIFilename(output), writer.GetAsyncAPI(), os.ModePerm^0111); err != nil {
+			return nil, err
+		}
+	}
+	if outputs.splitByService {
+		return saveServices(writer, filepath.Dir(output))
+	}
+	if outputs.merge {
+		return []string{output}, saveMerged(writer.Swagger, output)
+	}
+	return []string{output}, writer.Save(output)
+}
+
+// asyncAPIFilename derives the AsyncAPI output filename, e.g.
+// example.swagger.json becomes example.asyncapi.json.
+func asyncAPIFilename(output string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(output, ".json"), ".swagger")
+	return base + ".asyncapi.json"
+}
+
+func saveServices(writer *swagger.Writer, dir string) ([]string, error) {
+	result := []string{}
+	for _, service := range writer.Services() {
+		output := filepath.Join(dir, service+".swagger.json")
+		if err := ioutil.WriteFile(output, writer.GetService(service), os.ModePerm^0111); err != nil {
+			return nil, err
+		}
+		result = append(result, output)
+	}
+	return result, nil
+}
+
+// parseManifest runs parse for each of the manifest jobs, falling
+// back to the command line values for unset job fields. The written
+// swagger files are returned in the order of the jobs.
+func parseManifest(manifest *config.Manifest, hostname, prefix string, parallel bool, outputs outputOptions, opts ...swagger.WriterOption) ([]string, error) {
+	run := func(job config.Job) ([]string, error) {
+		if job.Host == "" {
+			job.Host = hostname
+		}
+		if job.PathPrefix == "" {
+			job.PathPrefix = prefix
+		}
+		jobOpts := append([]swagger.WriterOption{}, opts...)
+		if job.Version != "" {
+			jobOpts = append(jobOpts, swagger.WithVersion(job.Version))
+		}
+		if job.Title != "" {
+			jobOpts = append(jobOpts, swagger.WithTitle(job.Title))
+		}
+		if job.Description != "" {
+			jobOpts = append(jobOpts, swagger.WithDescription(job.Description))
+		}
+		files, err := parse(job.Host, job.In, job.Out, job.PathPrefix, outputs, jobOpts...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "job %s", job.In)
+		}
+		return files, nil
+	}
+
+	var (
+		wg    sync.WaitGroup
+		files = make([][]string, len(manifest.Jobs))
+		errs  = make([]error, len(manifest.Jobs))
+	)
+	for k, job := range manifest.Jobs {
+		if !parallel {
+			if files[k], errs[k] = run(job); errs[k] != nil {
+				return nil, errs[k]
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(k int, job config.Job) {
+			defer wg.Done()
+			files[k], errs[k] = run(job)
+		}(k, job)
+	}
+	wg.Wait()
+
+	result := []string{}
+	for k, err := range errs {
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, files[k]...)
 	}
-	return writer.Save(output)
+	return result, nil
 }
 
 func main() {
@@ -31,24 +156,282 @@ func main() {
 		out        string
 		host       string
 		pathPrefix string
+		version    string
+
+		versionInPath bool
+		manifest      string
+		configFile    string
+		parallel      bool
+
+		visibilityOption string
+		splitByService   bool
+		asyncAPI         bool
+		emitSourceInfo   bool
+		fieldsSuffix     bool
+
+		warnDeprecatedServices bool
+		lint                   bool
+		responsesFile          string
+		definitionSeparator    string
+		fieldOrder             = config.NewChoice("", swagger.FieldOrderDeclaration, swagger.FieldOrderProtoNumber)
+		gatewayOptions         bool
+		schemaRegistryURL      string
+		generatorInfo          bool
+		parallelImports        int
+		formatPatterns         bool
+		overlayFile            string
+		overlayArrays          string
+		protoPaths             config.StringList
+		serviceTags            config.StringList
+		inlineEnums            bool
+		merge                  bool
+		generatedBy            bool
+		useAllOf               bool
+		index                  string
+		onlyPackages           config.StringList
+		int64AsString          bool
+		title                  string
+		strictObjects          bool
+		description            string
+		reportFile             string
+		strict                 bool
+		skipWellKnown          bool
+		definitionsOnly        bool
+		wrapRefs               bool
+		contactName            string
+		contactEmail           string
+		contactURL             string
+		termsOfService         string
+		logFormat              string
+		logLevel               string
+		skip                   config.StringList
+		paginationTokenField   string
+		definitionOrder        bool
+		autoTitles             bool
+		noAuth                 bool
+		authRequired           bool
+		extensions             config.Extensions
+		watchFiles             bool
+		renames                config.Renames
+		apiPrefix              string
 	)
 	flag.StringVar(&in, "in", "", "Input source .proto file")
 	flag.StringVar(&out, "out", "", "Output swagger.json file")
 	flag.StringVar(&host, "host", "api.example.com", "API host name")
 	flag.StringVar(&pathPrefix, "pathPrefix", "/twirp", "Twrirp server path prefix")
+	flag.StringVar(&version, "version", "", "API version")
+	flag.StringVar(&title, "title", "", "API title, defaults to the proto file name")
+	flag.StringVar(&description, "description", "", "API description")
+	flag.StringVar(&contactName, "contact_name", "", "API contact name")
+	flag.StringVar(&contactEmail, "contact_email", "", "API contact email")
+	flag.StringVar(&contactURL, "contact_url", "", "API contact url")
+	flag.StringVar(&termsOfService, "terms_of_service", "", "API terms of service url")
+	flag.BoolVar(&noAuth, "no_auth", false, "Leave out all security definitions and requirements")
+	flag.BoolVar(&authRequired, "auth_required", true, "Keep the security requirements, false keeps only the security definitions")
+	flag.Var(&extensions, "extension", "Vendor extension key=value added to the document, JSON values start with { or [, may be repeated")
+	flag.StringVar(&apiPrefix, "api_prefix", "", "Prefix for all paths, before -pathPrefix, e.g. /v1")
+	flag.BoolVar(&versionInPath, "version_in_path", false, "Prefix paths with the major version, e.g. /v1")
+	flag.StringVar(&configFile, "config", "", "YAML or JSON file with flag values, flags take precedence")
+	flag.StringVar(&manifest, "manifest", "", "Manifest .yaml file listing generation jobs")
+	flag.BoolVar(&parallel, "parallel", false, "Run manifest jobs concurrently")
+	flag.StringVar(&visibilityOption, "visibility_option", "", "Proto option emitted as x-visibility")
+	flag.BoolVar(&splitByService, "split_by_service", false, "Write {service}.swagger.json files next to -out")
+	flag.BoolVar(&emitSourceInfo, "emit_source_info", false, "Emit x-proto-source with proto file and line")
+	flag.BoolVar(&fieldsSuffix, "fields_suffix", true, "Append the field order to definition descriptions")
+	flag.BoolVar(&warnDeprecatedServices, "warn_deprecated_services", false, "Warn about services where all rpcs are deprecated")
+	flag.BoolVar(&lint, "lint", false, "Lint the generated swagger document for a subset of the swagger 2.0 rules")
+	flag.BoolVar(&strict, "strict", false, "Fail on refs without a definition instead of warning")
+	flag.StringVar(&responsesFile, "responses", "", "JSON file with reusable responses keyed by status code")
+	flag.StringVar(&definitionSeparator, "definition_separator", "_", "Separator between package and message in definition names")
+	flag.Var(fieldOrder, "field_order", "Emit x-order on fields: declaration or proto_number")
+	flag.Var(&renames, "rename_definition", "Rename definitions with from:to pairs, e.g. com.example.Foo:Foo, may be repeated or comma separated")
+	flag.BoolVar(&autoTitles, "auto_titles", true, "Title definitions without a comment by the message name")
+	flag.BoolVar(&definitionOrder, "definition_order", false, "Emit x-order on definitions in declaration order")
+	flag.BoolVar(&asyncAPI, "asyncapi", false, "Also write an AsyncAPI document for streaming rpcs")
+	flag.BoolVar(&gatewayOptions, "gateway_options", false, "Read grpc-gateway openapiv2 options on rpcs and messages")
+	flag.StringVar(&schemaRegistryURL, "schema_registry_url", "", "Schema registry URL emitted for messages with @schema-id")
+	flag.BoolVar(&generatorInfo, "generator_info", false, "Record the generator version and input in x-generator")
+	flag.IntVar(&parallelImports, "parallel_imports", 1, "Number of imported files to parse concurrently")
+	flag.BoolVar(&formatPatterns, "format_patterns", false, "Emit patterns for string fields tagged with format:uuid, email or ipv4")
+	flag.StringVar(&overlayFile, "overlay", "", "Partial swagger JSON file merged onto the generated document")
+	flag.StringVar(&overlayArrays, "overlay_arrays", swagger.OverlayArraysAppend, "Merge overlay arrays: append or replace")
+	flag.Var(&skip, "skip", "Inputs which don't produce files, takes precedence over -in and manifest jobs")
+	flag.Var(&protoPaths, "proto_path", "Directory to search for imports, may be repeated or comma separated")
+	flag.Var(&serviceTags, "tags", "Only include services annotated with one of these @tag values")
+	flag.BoolVar(&inlineEnums, "inline_enums", false, "List enum values on fields instead of referencing the enum")
+	flag.BoolVar(&merge, "merge", false, "Merge the generated definitions and paths into an existing -out file")
+	flag.BoolVar(&generatedBy, "generated_by", false, "Record the generator version, input and time in x-generated-by")
+	flag.BoolVar(&useAllOf, "use_allof", false, "Emit messages with a single message field as allOf")
+	flag.BoolVar(&wrapRefs, "wrap_refs", false, "Wrap refs of commented fields in allOf, so the comments aren't dropped")
+	flag.StringVar(&index, "index", "", "Write a JSON index of the generated files with their title and version")
+	flag.Var(&onlyPackages, "only_packages", "Only emit definitions from these packages and the main file package")
+	flag.BoolVar(&int64AsString, "int64_as_string", true, "Type 64bit integers as strings, as JavaScript numbers lose precision")
+	flag.BoolVar(&strictObjects, "strict_objects", false, "Set additionalProperties: false on message definitions")
+	flag.BoolVar(&skipWellKnown, "skip_well_known", false, "Leave out google.protobuf definitions, refs to them are kept")
+	flag.BoolVar(&definitionsOnly, "definitions_only", false, "Only emit the definitions, without paths")
+	flag.StringVar(&paginationTokenField, "pagination_token_field", "next_page_token", "Response field marking paginated rpcs with x-ms-pageable, empty to disable")
+	flag.BoolVar(&watchFiles, "watch", false, "Regenerate when the input or the .proto files in -proto_path change")
+	flag.StringVar(&reportFile, "report", "", "Write a JSON report of skipped imports, unresolved refs and warnings")
+	flag.StringVar(&logFormat, "log_format", "text", "Log format: text or json")
+	flag.StringVar(&logLevel, "log_level", "info", "Log level: debug, info, warn or error")
 	flag.Parse()
 
-	if in == "" {
-		log.Fatalf("Missing parameter: -in [input.proto]")
+	if configFile != "" {
+		cfg, err := config.Load(configFile)
+		if err != nil {
+			fatal("exit with error", "error", err)
+		}
+		if err := cfg.Apply(flag.CommandLine); err != nil {
+			fatal("exit with error", "error", err)
+		}
+	}
+
+	if err := config.SetupLogging(logFormat, logLevel); err != nil {
+		fatal("exit with error", "error", err)
+	}
+
+	opts := []swagger.WriterOption{
+		swagger.WithVersion(version),
+		swagger.WithTitle(title),
+		swagger.WithDescription(description),
+		swagger.WithContact(contactName, contactEmail, contactURL),
+		swagger.WithTermsOfService(termsOfService),
+		swagger.WithExtensions(extensions),
+		swagger.WithNoAuth(noAuth),
+		swagger.WithAuthRequired(authRequired),
+		swagger.WithVersionInPath(versionInPath),
+		swagger.WithAPIPrefix(apiPrefix),
+		swagger.WithVisibilityOption(visibilityOption),
+		swagger.WithSourceInfo(emitSourceInfo),
+		swagger.WithFieldsSuffix(fieldsSuffix),
+		swagger.WithWarnDeprecatedServices(warnDeprecatedServices),
+		swagger.WithLint(lint),
+		swagger.WithStrict(strict),
+		swagger.WithDefinitionSeparator(definitionSeparator),
+		swagger.WithFieldOrder(fieldOrder.Value),
+		swagger.WithDefinitionOrder(definitionOrder),
+		swagger.WithAutoTitles(autoTitles),
+		swagger.WithRenames(renames),
+		swagger.WithGatewayOptions(gatewayOptions),
+		swagger.WithSchemaRegistryURL(schemaRegistryURL),
+		swagger.WithGeneratorInfo(generatorInfo),
+		swagger.WithParallelImports(parallelImports),
+		swagger.WithFormatPatterns(formatPatterns),
+		swagger.WithProtoPaths(protoPaths),
+		swagger.WithServiceTags(serviceTags),
+		swagger.WithInlineEnums(inlineEnums),
+		swagger.WithGeneratedBy(generatedBy),
+		swagger.WithAllOf(useAllOf),
+		swagger.WithWrapRefs(wrapRefs),
+		swagger.WithOnlyPackages(onlyPackages),
+		swagger.WithInt64AsString(int64AsString),
+		swagger.WithStrictObjects(strictObjects),
+		swagger.WithGeneratorVersion(buildVersion),
+		swagger.WithSkipWellKnown(skipWellKnown),
+		swagger.WithDefinitionsOnly(definitionsOnly),
+		swagger.WithPaginationTokenField(paginationTokenField),
 	}
-	if out == "" {
-		log.Fatalf("Missing parameter: -out [output.proto]")
+
+	if responsesFile != "" {
+		responses, err := swagger.LoadResponses(responsesFile)
+		if err != nil {
+			fatal("exit with error", "error", err)
+		}
+		opts = append(opts, swagger.WithResponses(responses))
+	}
+
+	if overlayFile != "" {
+		overlay, err := swagger.LoadOverlay(overlayFile)
+		if err != nil {
+			fatal("exit with error", "error", err)
+		}
+		opts = append(opts, swagger.WithOverlay(overlay, overlayArrays))
+	}
+
+	outputs := outputOptions{
+		splitByService: splitByService,
+		asyncAPI:       asyncAPI,
+		merge:          merge,
+		skip:           skip,
+	}
+
+	var (
+		m      *config.Manifest
+		inputs []string
+	)
+	if manifest != "" {
+		var err error
+		if m, err = config.LoadManifest(manifest); err != nil {
+			fatal("exit with error", "error", err)
+		}
+		for _, job := range m.Jobs {
+			inputs = append(inputs, job.In)
+		}
+	} else {
+		if in == "" {
+			fatal("Missing parameter: -in [input.proto]")
+		}
+		if out == "" {
+			fatal("Missing parameter: -out [output.proto]")
+		}
+		if host == "" {
+			fatal("Missing parameter: -host [api.example.com]")
+		}
+		if versionInPath && version == "" {
+			fatal("Missing parameter: -version [1.0.0], required by -version_in_path")
+		}
+		inputs = []string{in}
 	}
-	if host == "" {
-		log.Fatalf("Missing parameter: -host [api.example.com]")
+
+	// Each run gets a new report and parse cache, so watching doesn't
+	// keep stale imports or diagnostics from the previous runs. The
+	// files walked by the run are returned for watching.
+	generate := func() ([]string, error) {
+		report := swagger.NewReport()
+		walked := &walkedFiles{}
+		runOpts := append(opts[:len(opts):len(opts)],
+			swagger.WithReport(report),
+			swagger.WithParseCache(swagger.NewParseCache()),
+			walked.option(),
+		)
+
+		var (
+			files []string
+			err   error
+		)
+		if m != nil {
+			files, err = parseManifest(m, host, pathPrefix, parallel, outputs, runOpts...)
+		} else {
+			files, err = parse(host, in, out, pathPrefix, outputs, runOpts...)
+		}
+
+		// The report is written when generation fails too, as it
+		// tells what went wrong.
+		if reportFile != "" {
+			if err := report.Save(reportFile); err != nil {
+				return walked.files(), err
+			}
+		}
+		if err != nil {
+			return walked.files(), err
+		}
+		if index != "" {
+			return walked.files(), writeIndex(index, files)
+		}
+		return walked.files(), nil
 	}
 
-	if err := parse(host, in, out, pathPrefix); err != nil {
-		log.WithError(err).Fatal("exit with error")
+	if watchFiles {
+		watch(inputs, protoPaths, generate)
+		return
+	}
+	if _, err := generate(); err != nil {
+		fatal("exit with error", "error", err)
 	}
 }
+
+// fatal logs the message and exits with a non-zero status.
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestOutputOptionsSkipped(t *testing.T) {
+	outputs := outputOptions{
+		skip: []string{"proto/common.proto", "./proto/types.proto"},
+	}
+
+	testCases := []struct {
+		in   string
+		want bool
+	}{
+		{"proto/common.proto", true},
+		{"./proto/common.proto", true},
+		{"proto/types.proto", true},
+		{"proto/service.proto", false},
+		{"common.proto", false},
+	}
+
+	for _, tc := range testCases {
+		if got := outputs.skipped(tc.in); got != tc.want {
+			t.Errorf("%q: got %t, want %t", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"reflect"
+
+	"github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+// mergeSwagger merges the generated definitions, paths and tags into
+// an existing document. Generated entries replace existing entries of
+// the same name, everything else in base is kept as is.
+func mergeSwagger(base, overlay *spec.Swagger) *spec.Swagger {
+	result := *base
+
+	result.Definitions = make(spec.Definitions)
+	for name, schema := range base.Definitions {
+		result.Definitions[name] = schema
+	}
+	for name, schema := range overlay.Definitions {
+		if existing, ok := result.Definitions[name]; ok && !reflect.DeepEqual(existing, schema) {
+			slog.Info("merge: replacing definition", "definition", name)
+		}
+		result.Definitions[name] = schema
+	}
+
+	result.Paths = &spec.Paths{
+		Paths: make(map[string]spec.PathItem),
+	}
+	if base.Paths != nil {
+		result.Paths.VendorExtensible = base.Paths.VendorExtensible
+		for name, item := range base.Paths.Paths {
+			result.Paths.Paths[name] = item
+		}
+	}
+	if overlay.Paths != nil {
+		for name, item := range overlay.Paths.Paths {
+			if existing, ok := result.Paths.Paths[name]; ok && !reflect.DeepEqual(existing, item) {
+				slog.Info("merge: replacing path", "path", name)
+			}
+			result.Paths.Paths[name] = item
+		}
+	}
+
+	result.Tags = append([]spec.Tag{}, base.Tags...)
+	for _, tag := range overlay.Tags {
+		replaced := false
+		for k, existing := range result.Tags {
+			if existing.Name == tag.Name {
+				result.Tags[k] = tag
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result.Tags = append(result.Tags, tag)
+		}
+	}
+	return &result
+}
+
+// saveMerged merges the generated document into the output file if
+// it exists, or writes the generated document otherwise.
+func saveMerged(generated *spec.Swagger, output string) error {
+	body, err := ioutil.ReadFile(output)
+	if os.IsNotExist(err) {
+		return writeSwagger(generated, output)
+	}
+	if err != nil {
+		return err
+	}
+
+	existing := &spec.Swagger{}
+	if err := json.Unmarshal(body, existing); err != nil {
+		return errors.Wrapf(err, "can't merge into %s", output)
+	}
+	return writeSwagger(mergeSwagger(existing, generated), output)
+}
+
+func writeSwagger(document *spec.Swagger, output string) error {
+	body, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, body, os.ModePerm^0111)
+}
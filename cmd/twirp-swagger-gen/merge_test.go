@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func testSwagger(definitions map[string]string, paths []string, tags []string) *spec.Swagger {
+	result := &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Swagger:     "2.0",
+			Definitions: make(spec.Definitions),
+			Paths: &spec.Paths{
+				Paths: make(map[string]spec.PathItem),
+			},
+		},
+	}
+	for name, typeName := range definitions {
+		result.Definitions[name] = *spec.StringProperty().Typed(typeName, "")
+	}
+	for _, name := range paths {
+		result.Paths.Paths[name] = spec.PathItem{
+			PathItemProps: spec.PathItemProps{
+				Post: spec.NewOperation(name),
+			},
+		}
+	}
+	for _, name := range tags {
+		result.Tags = append(result.Tags, spec.NewTag(name, "", nil))
+	}
+	return result
+}
+
+func TestMergeSwagger(t *testing.T) {
+	base := testSwagger(
+		map[string]string{"auth_Token": "object", "api_Shared": "string"},
+		[]string{"/auth/token", "/twirp/api.Service/Get"},
+		[]string{"Auth", "Service"},
+	)
+	base.Info = &spec.Info{InfoProps: spec.InfoProps{Title: "Hand-written"}}
+	generated := testSwagger(
+		map[string]string{"api_Shared": "object", "api_Request": "object"},
+		[]string{"/twirp/api.Service/Get", "/twirp/api.Service/List"},
+		[]string{"Service", "Other"},
+	)
+	generated.Paths.Paths["/twirp/api.Service/Get"].Post.Summary = "generated"
+
+	merged := mergeSwagger(base, generated)
+
+	t.Run("definitions", func(t *testing.T) {
+		want := map[string]string{
+			"auth_Token":  "object",
+			"api_Shared":  "object",
+			"api_Request": "object",
+		}
+		if len(merged.Definitions) != len(want) {
+			t.Errorf("got %d definitions, want %d", len(merged.Definitions), len(want))
+		}
+		for name, typeName := range want {
+			schema, ok := merged.Definitions[name]
+			if !ok {
+				t.Errorf("missing definition %s", name)
+				continue
+			}
+			if !schema.Type.Contains(typeName) {
+				t.Errorf("definition %s: got type %v, want %s", name, schema.Type, typeName)
+			}
+		}
+	})
+
+	t.Run("paths", func(t *testing.T) {
+		for _, name := range []string{"/auth/token", "/twirp/api.Service/Get", "/twirp/api.Service/List"} {
+			if _, ok := merged.Paths.Paths[name]; !ok {
+				t.Errorf("missing path %s", name)
+			}
+		}
+		if got := merged.Paths.Paths["/twirp/api.Service/Get"].Post.Summary; got != "generated" {
+			t.Errorf("conflicting path: got summary %q, want the generated one", got)
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		names := []string{}
+		for _, tag := range merged.Tags {
+			names = append(names, tag.Name)
+		}
+		want := []string{"Auth", "Service", "Other"}
+		if len(names) != len(want) {
+			t.Fatalf("got tags %v, want %v", names, want)
+		}
+		for k := range want {
+			if names[k] != want[k] {
+				t.Errorf("got tags %v, want %v", names, want)
+				break
+			}
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		if merged.Info == nil || merged.Info.Title != "Hand-written" {
+			t.Errorf("info from the existing document wasn't kept: %+v", merged.Info)
+		}
+		if _, ok := base.Definitions["api_Request"]; ok {
+			t.Errorf("base document was modified")
+		}
+	})
+}
+
+func TestSaveMerged(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.swagger.json")
+
+	// without an existing file the generated document is written
+	if err := saveMerged(testSwagger(map[string]string{"auth_Token": "object"}, []string{"/auth/token"}, nil), output); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveMerged(testSwagger(map[string]string{"api_Request": "object"}, []string{"/twirp/api.Service/Get"}, nil), output); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &spec.Swagger{}
+	if err := json.Unmarshal(body, result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Definitions) != 2 || len(result.Paths.Paths) != 2 {
+		t.Errorf("got %d definitions and %d paths, want 2 of each", len(result.Definitions), len(result.Paths.Paths))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-bridget/twirp-swagger-gen/internal/swagger"
+)
+
+// watchInterval is how often the watched files are checked for changes.
+const watchInterval = 500 * time.Millisecond
+
+// watch runs generate, and runs it again each time one of the inputs,
+// the files walked by the last run, or the .proto files in the proto
+// paths change. The walked files include the resolved imports, so
+// imports found relative to the working directory are watched too.
+// The files are polled for their modification times, which needs no
+// dependency beyond the standard library and works the same with
+// editors which replace files on save. Errors are logged, as watching
+// only stops when the process is interrupted.
+func watch(inputs, protoPaths []string, generate func() ([]string, error)) {
+	for {
+		walked, err := generate()
+		if err != nil {
+			slog.Error("generate failed", "error", err)
+		}
+		watched := append(append([]string{}, inputs...), walked...)
+		files := snapshot(watched, protoPaths)
+		if err == nil {
+			slog.Info("generated", "watched", len(files))
+		}
+
+		for {
+			time.Sleep(watchInterval)
+			if changed(files, snapshot(watched, protoPaths)) {
+				break
+			}
+		}
+	}
+}
+
+// walkedFiles collects the writers of a run, to list the files they
+// walked. Manifest jobs may run in parallel, so it's safe for
+// concurrent use.
+type walkedFiles struct {
+	mu      sync.Mutex
+	writers []*swagger.Writer
+}
+
+// option records the writer it's applied to.
+func (w *walkedFiles) option() swagger.WriterOption {
+	return func(sw *swagger.Writer) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.writers = append(w.writers, sw)
+	}
+}
+
+// files returns the files walked by all the writers.
+func (w *walkedFiles) files() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	result := []string{}
+	for _, writer := range w.writers {
+		result = append(result, writer.Files()...)
+	}
+	return result
+}
+
+// snapshot returns the modification times of the files and the .proto
+// files in the proto paths. Missing files are left out, so a file which
+// is deleted and written again is seen as changed.
+func snapshot(files, protoPaths []string) map[string]time.Time {
+	result := make(map[string]time.Time)
+	for _, filename := range files {
+		if info, err := os.Stat(filename); err == nil {
+			result[filename] = info.ModTime()
+		}
+	}
+	for _, dir := range protoPaths {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() && filepath.Ext(path) == ".proto" {
+				result[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return result
+}
+
+// changed reports if files were added, removed or modified between
+// the snapshots.
+func changed(before, after map[string]time.Time) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for name, modTime := range after {
+		if previous, ok := before[name]; !ok || !previous.Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
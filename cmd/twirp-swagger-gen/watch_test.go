@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-bridget/twirp-swagger-gen/internal/swagger"
+)
+
+func TestChanged(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Second)
+	before := map[string]time.Time{
+		"service.proto": now,
+		"common.proto":  now,
+	}
+
+	testCases := []struct {
+		name  string
+		after map[string]time.Time
+		want  bool
+	}{
+		{"unchanged", map[string]time.Time{"service.proto": now, "common.proto": now}, false},
+		{"modified", map[string]time.Time{"service.proto": later, "common.proto": now}, true},
+		{"removed", map[string]time.Time{"service.proto": now}, true},
+		{"added", map[string]time.Time{"service.proto": now, "common.proto": now, "types.proto": now}, true},
+		{"renamed", map[string]time.Time{"service.proto": now, "types.proto": now}, true},
+	}
+
+	for _, tc := range testCases {
+		if got := changed(before, tc.after); got != tc.want {
+			t.Errorf("%s: got %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWatchImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"service.proto": "syntax = \"proto3\";\npackage svc;\nimport \"dep.proto\";\nservice Svc {\n\trpc Get(dep.Request) returns (dep.Request);\n}\n",
+		"dep.proto":     "syntax = \"proto3\";\npackage dep;\nmessage Request {\n\tstring id = 1;\n}\n",
+	}
+	for name, body := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// imports are resolved relative to the working directory, which
+	// isn't a proto path, so only the walked files cover them
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	walked := &walkedFiles{}
+	writer := swagger.NewWriter("service.proto", "api.example.com", "/twirp", walked.option())
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	watched := append([]string{"service.proto"}, walked.files()...)
+	before := snapshot(watched, nil)
+	if _, ok := before["dep.proto"]; !ok {
+		t.Fatalf("dep.proto isn't watched, got %v", watched)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes("dep.proto", later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !changed(before, snapshot(watched, nil)) {
+		t.Error("touching the imported dep.proto wasn't seen as a change")
+	}
+}
@@ -0,0 +1,56 @@
+package asyncapi
+
+import "encoding/json"
+
+// Version is the AsyncAPI specification version of the document.
+const Version = "2.6.0"
+
+// Document is the subset of an AsyncAPI 2.x document needed to
+// describe streaming rpcs.
+type Document struct {
+	AsyncAPI   string             `json:"asyncapi"`
+	Info       Info               `json:"info"`
+	Channels   map[string]Channel `json:"channels"`
+	Components *Components        `json:"components,omitempty"`
+}
+
+// Info holds the document title and version.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Channel describes an address where messages are exchanged. Publish
+// holds the messages a client sends, Subscribe the ones it receives.
+type Channel struct {
+	Description string     `json:"description,omitempty"`
+	Publish     *Operation `json:"publish,omitempty"`
+	Subscribe   *Operation `json:"subscribe,omitempty"`
+}
+
+// Operation describes the message sent or received on a channel.
+type Operation struct {
+	OperationID string  `json:"operationId"`
+	Summary     string  `json:"summary,omitempty"`
+	Message     Message `json:"message"`
+}
+
+// Message references the payload schema of a message.
+type Message struct {
+	Payload Reference `json:"payload"`
+}
+
+// Reference is a JSON reference to a schema.
+type Reference struct {
+	Ref string `json:"$ref"`
+}
+
+// Components holds the reusable schemas, referenced from messages.
+type Components struct {
+	Schemas map[string]json.RawMessage `json:"schemas,omitempty"`
+}
+
+// SchemaRef returns a reference to a component schema.
+func SchemaRef(name string) Reference {
+	return Reference{Ref: "#/components/schemas/" + name}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds option values from a YAML or JSON file, keyed by the
+// flag names of the command, e.g. `hostname: api.example.com`.
+//
+// Precedence, from lowest to highest: flag defaults, config file
+// values, and flags set on the command line (or as plugin options).
+type Config struct {
+	Options map[string]Value
+}
+
+// Value is a config option value, as the YAML text of a scalar or of
+// each item in a list. The text is kept as written, so `version: 1.10`
+// isn't read as the number 1.1.
+type Value []string
+
+// UnmarshalYAML reads a scalar or a list of scalars. Nested objects
+// have no flag to set, so they're an error.
+func (v *Value) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var scalar string
+	if err := unmarshal(&scalar); err == nil {
+		*v = Value{scalar}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*v = Value(list)
+		return nil
+	}
+	return fmt.Errorf("want a value or a list of values")
+}
+
+// Load reads a config file. JSON is accepted as it is valid YAML.
+func Load(filename string) (*Config, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Config{
+		Options: make(map[string]Value),
+	}
+	if err := yaml.Unmarshal(body, &result.Options); err != nil {
+		return nil, fmt.Errorf("can't decode config %s: %w", filename, err)
+	}
+	return result, nil
+}
+
+// Apply sets the flags which were not already set explicitly. List
+// items are set one by one, like a repeated flag. Unknown option
+// names are an error.
+func (c *Config) Apply(flags *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	names := make([]string, 0, len(c.Options))
+	for name := range c.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if flags.Lookup(name) == nil {
+			return fmt.Errorf("unknown config option %q", name)
+		}
+		if explicit[name] {
+			continue
+		}
+		for _, item := range c.Options[name] {
+			if err := flags.Set(name, item); err != nil {
+				return fmt.Errorf("config option %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Apply(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	body := strings.Join([]string{
+		"version: 1.10",
+		"title: 2.0",
+		"host: api.example.com",
+		"lint: true",
+		"proto_path: [third_party, api]",
+		"extension:",
+		`  - 'x-owner={"team":"api","tier":1}'`,
+		"  - x-audience=public",
+	}, "\n")
+	if err := ioutil.WriteFile(filename, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		flags      = flag.NewFlagSet("test", flag.ContinueOnError)
+		version    = flags.String("version", "", "")
+		title      = flags.String("title", "", "")
+		host       = flags.String("host", "", "")
+		lint       = flags.Bool("lint", false, "")
+		protoPaths StringList
+		extensions Extensions
+	)
+	flags.Var(&protoPaths, "proto_path", "")
+	flags.Var(&extensions, "extension", "")
+	if err := flags.Parse([]string{"-host", "cli.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+
+	if *version != "1.10" || *title != "2.0" {
+		t.Errorf("got version %q and title %q, want the values as written", *version, *title)
+	}
+	if *host != "cli.example.com" {
+		t.Errorf("got host %q, want the command line value", *host)
+	}
+	if !*lint {
+		t.Error("lint wasn't set")
+	}
+	if want := (StringList{"third_party", "api"}); !reflect.DeepEqual(protoPaths, want) {
+		t.Errorf("got proto paths %v, want %v", protoPaths, want)
+	}
+	want := Extensions{
+		"x-owner":    map[string]interface{}{"team": "api", "tier": float64(1)},
+		"x-audience": "public",
+	}
+	if !reflect.DeepEqual(extensions, want) {
+		t.Errorf("got extensions %v, want %v", extensions, want)
+	}
+}
+
+func TestLoad_NestedObject(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(filename, []byte("contact:\n  name: API Team\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(filename); err == nil {
+		t.Error("expected an error for a nested object")
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StringList is a flag which may be repeated, or given a comma
+// separated list of values, e.g. `-proto_path a,b -proto_path c`.
+type StringList []string
+
+func (s *StringList) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *StringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*s = append(*s, item)
+		}
+	}
+	return nil
+}
+
+// Extensions is a flag for vendor extensions, which may be repeated,
+// e.g. `-extension x-audience=public -extension x-owner={"team":"api"}`.
+// Values starting with `{` or `[` are parsed as JSON.
+type Extensions map[string]interface{}
+
+func (e *Extensions) String() string {
+	if e == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(*e))
+	for key := range *e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func (e *Extensions) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid extension %q, want key=value", value)
+	}
+	key, raw := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if !strings.HasPrefix(strings.ToLower(key), "x-") {
+		return fmt.Errorf("invalid extension %q, the key must start with x-", value)
+	}
+
+	var parsed interface{} = raw
+	if strings.HasPrefix(raw, "{") || strings.HasPrefix(raw, "[") {
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			return fmt.Errorf("invalid extension %q: %w", value, err)
+		}
+	}
+	if *e == nil {
+		*e = make(Extensions)
+	}
+	(*e)[key] = parsed
+	return nil
+}
+
+// Renames is a flag for `from:to` pairs, which may be repeated, or given
+// a comma separated list, e.g. `-rename_definition com.example.Foo:Foo`.
+type Renames map[string]string
+
+func (r *Renames) String() string {
+	if r == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*r))
+	for from, to := range *r {
+		pairs = append(pairs, from+":"+to)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (r *Renames) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return fmt.Errorf("invalid rename %q, want from:to", item)
+		}
+		if *r == nil {
+			*r = make(Renames)
+		}
+		(*r)[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return nil
+}
+
+// Choice is a flag which only accepts one of the allowed values, or an
+// empty value for the default, e.g. `-field_order proto_number`.
+type Choice struct {
+	Value   string
+	Allowed []string
+}
+
+// NewChoice creates a choice flag with a default value.
+func NewChoice(value string, allowed ...string) *Choice {
+	return &Choice{
+		Value:   value,
+		Allowed: allowed,
+	}
+}
+
+func (c *Choice) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.Value
+}
+
+func (c *Choice) Set(value string) error {
+	if value != "" {
+		valid := false
+		for _, allowed := range c.Allowed {
+			valid = valid || value == allowed
+		}
+		if !valid {
+			return fmt.Errorf("invalid value %q, want one of %s", value, strings.Join(c.Allowed, ", "))
+		}
+	}
+	c.Value = value
+	return nil
+}
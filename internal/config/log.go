@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// SetupLogging sets the default logger, writing to stderr as text or
+// json, with the level one of debug, info, warn or error.
+func SetupLogging(format, level string) error {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q, want debug, info, warn or error", level)
+	}
+
+	options := &slog.HandlerOptions{
+		Level: logLevel,
+	}
+	switch format {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, options)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, options)))
+	default:
+		return fmt.Errorf("invalid log format %q, want text or json", format)
+	}
+	return nil
+}
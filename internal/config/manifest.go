@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest describes a list of generation jobs.
+type Manifest struct {
+	Jobs []Job `yaml:"jobs"`
+}
+
+// Job describes a single proto to swagger generation. Empty values
+// fall back to the command line flags.
+type Job struct {
+	In      string `yaml:"in"`
+	Out     string `yaml:"out"`
+	Host    string `yaml:"host"`
+	Version string `yaml:"version"`
+	Title   string `yaml:"title"`
+
+	Description string `yaml:"description"`
+	PathPrefix  string `yaml:"path_prefix"`
+}
+
+// LoadManifest reads and validates a manifest file.
+func LoadManifest(filename string) (*Manifest, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Manifest{}
+	if err := yaml.UnmarshalStrict(body, result); err != nil {
+		return nil, fmt.Errorf("can't decode manifest %s: %w", filename, err)
+	}
+
+	for k, job := range result.Jobs {
+		if job.In == "" {
+			return nil, fmt.Errorf("manifest job %d: missing in", k)
+		}
+		if job.Out == "" {
+			return nil, fmt.Errorf("manifest job %d: missing out", k)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,291 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+
+	"github.com/go-openapi/spec"
+)
+
+// Change describes a single API difference between two documents.
+type Change struct {
+	Breaking bool
+	Message  string
+}
+
+func (c Change) String() string {
+	if c.Breaking {
+		return "BREAKING: " + c.Message
+	}
+	return "non-breaking: " + c.Message
+}
+
+// Load reads a swagger document as produced by the generator.
+func Load(filename string) (*spec.Swagger, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	result := &spec.Swagger{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("can't decode %s: %w", filename, err)
+	}
+	return result, nil
+}
+
+// Compare lists the changes between the before and after documents.
+func Compare(before, after *spec.Swagger) []Change {
+	result := []Change{}
+	result = append(result, comparePaths(paths(before), paths(after))...)
+	result = append(result, compareDefinitions(before.Definitions, after.Definitions)...)
+	return result
+}
+
+// HasBreaking returns true if any of the changes are breaking.
+func HasBreaking(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+func paths(sw *spec.Swagger) map[string]spec.PathItem {
+	if sw.Paths == nil {
+		return nil
+	}
+	return sw.Paths.Paths
+}
+
+func comparePaths(before, after map[string]spec.PathItem) []Change {
+	result := []Change{}
+	for _, name := range sortedKeys(before, after) {
+		oldPath, inBefore := before[name]
+		newPath, inAfter := after[name]
+		switch {
+		case !inAfter:
+			result = append(result, Change{true, "removed path " + name})
+		case !inBefore:
+			result = append(result, Change{false, "added path " + name})
+		default:
+			result = append(result, compareParameters(name, parameters(oldPath), parameters(newPath))...)
+			result = append(result, compareResponses(name, responses(oldPath), responses(newPath))...)
+		}
+	}
+	return result
+}
+
+func parameters(item spec.PathItem) map[string]spec.Parameter {
+	result := make(map[string]spec.Parameter)
+	if item.Post == nil {
+		return result
+	}
+	for _, param := range item.Post.Parameters {
+		result[param.In+":"+param.Name] = param
+	}
+	return result
+}
+
+func compareParameters(pathName string, before, after map[string]spec.Parameter) []Change {
+	result := []Change{}
+	for _, name := range sortedKeys(before, after) {
+		oldParam, inBefore := before[name]
+		newParam, inAfter := after[name]
+		switch {
+		case !inAfter:
+			result = append(result, Change{true, fmt.Sprintf("removed parameter %s from %s", name, pathName)})
+		case !inBefore:
+			result = append(result, Change{newParam.Required, fmt.Sprintf("added parameter %s to %s", name, pathName)})
+		case oldParam.Required != newParam.Required:
+			result = append(result, Change{newParam.Required, fmt.Sprintf("changed parameter %s on %s, required=%t", name, pathName, newParam.Required)})
+		case refOf(oldParam.Schema) != refOf(newParam.Schema):
+			result = append(result, Change{true, fmt.Sprintf("changed parameter %s on %s, schema %s -> %s", name, pathName, refOf(oldParam.Schema), refOf(newParam.Schema))})
+		}
+	}
+	return result
+}
+
+// responses returns the responses of the rpc keyed by status code,
+// with the default response under `default`.
+func responses(item spec.PathItem) map[string]spec.Response {
+	result := make(map[string]spec.Response)
+	if item.Post == nil || item.Post.Responses == nil {
+		return result
+	}
+	for code, response := range item.Post.Responses.StatusCodeResponses {
+		result[fmt.Sprint(code)] = response
+	}
+	if item.Post.Responses.Default != nil {
+		result["default"] = *item.Post.Responses.Default
+	}
+	return result
+}
+
+func compareResponses(pathName string, before, after map[string]spec.Response) []Change {
+	result := []Change{}
+	for _, code := range sortedKeys(before, after) {
+		oldResponse, inBefore := before[code]
+		newResponse, inAfter := after[code]
+		switch {
+		case !inAfter:
+			result = append(result, Change{true, fmt.Sprintf("removed response %s from %s", code, pathName)})
+		case !inBefore:
+			result = append(result, Change{false, fmt.Sprintf("added response %s to %s", code, pathName)})
+		case responseType(oldResponse) != responseType(newResponse):
+			result = append(result, Change{true, fmt.Sprintf("changed response %s on %s, schema %s -> %s", code, pathName, responseType(oldResponse), responseType(newResponse))})
+		}
+	}
+	return result
+}
+
+func responseType(response spec.Response) string {
+	if response.Schema == nil {
+		return "none"
+	}
+	return typeOf(*response.Schema)
+}
+
+func compareDefinitions(before, after spec.Definitions) []Change {
+	result := []Change{}
+	for _, name := range sortedKeys(before, after) {
+		oldSchema, inBefore := before[name]
+		newSchema, inAfter := after[name]
+		switch {
+		case !inAfter:
+			result = append(result, Change{true, "removed definition " + name})
+		case !inBefore:
+			result = append(result, Change{false, "added definition " + name})
+		default:
+			result = append(result, compareSchema(name, oldSchema, newSchema)...)
+		}
+	}
+	return result
+}
+
+func compareSchema(definition string, before, after spec.Schema) []Change {
+	result := []Change{}
+	oldParts, newParts := flatten(before), flatten(after)
+	for _, ref := range oldParts.refs {
+		if !contains(newParts.refs, ref) {
+			result = append(result, Change{true, fmt.Sprintf("removed allOf %s from %s", ref, definition)})
+		}
+	}
+	for _, ref := range newParts.refs {
+		if !contains(oldParts.refs, ref) {
+			result = append(result, Change{false, fmt.Sprintf("added allOf %s to %s", ref, definition)})
+		}
+	}
+
+	for _, name := range sortedKeys(oldParts.properties, newParts.properties) {
+		oldField, inBefore := oldParts.properties[name]
+		newField, inAfter := newParts.properties[name]
+		switch {
+		case !inAfter:
+			result = append(result, Change{true, fmt.Sprintf("removed field %s.%s", definition, name)})
+		case !inBefore:
+			required := contains(newParts.required, name)
+			result = append(result, Change{required, fmt.Sprintf("added field %s.%s", definition, name)})
+		case typeOf(oldField) != typeOf(newField):
+			result = append(result, Change{true, fmt.Sprintf("changed field %s.%s, type %s -> %s", definition, name, typeOf(oldField), typeOf(newField))})
+		}
+	}
+	for _, name := range newParts.required {
+		if _, existed := oldParts.properties[name]; existed && !contains(oldParts.required, name) {
+			result = append(result, Change{true, fmt.Sprintf("changed field %s.%s, now required", definition, name)})
+		}
+	}
+	for _, name := range oldParts.required {
+		if _, exists := newParts.properties[name]; exists && !contains(newParts.required, name) {
+			result = append(result, Change{false, fmt.Sprintf("changed field %s.%s, no longer required", definition, name)})
+		}
+	}
+	return result
+}
+
+// parts are the fields of a definition, with the inline allOf members
+// of -use_allof merged in, and the refs of the embedded definitions.
+type parts struct {
+	properties map[string]spec.Schema
+	required   []string
+	refs       []string
+}
+
+func flatten(schema spec.Schema) parts {
+	result := parts{
+		properties: make(map[string]spec.Schema),
+	}
+	var walk func(schema spec.Schema)
+	walk = func(schema spec.Schema) {
+		for name, property := range schema.Properties {
+			result.properties[name] = property
+		}
+		result.required = append(result.required, schema.Required...)
+		for _, member := range schema.AllOf {
+			if ref := refOf(&member); ref != "" {
+				result.refs = append(result.refs, ref)
+				continue
+			}
+			walk(member)
+		}
+	}
+	walk(schema)
+	return result
+}
+
+func typeOf(schema spec.Schema) string {
+	if ref := refOf(&schema); ref != "" {
+		return ref
+	}
+	// -wrap_refs moves the ref of documented fields into an allOf
+	if len(schema.AllOf) == 1 && refOf(&schema.AllOf[0]) != "" {
+		return refOf(&schema.AllOf[0])
+	}
+	result := fmt.Sprint([]string(schema.Type))
+	if schema.Format != "" {
+		result += "/" + schema.Format
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		result += " of " + typeOf(*schema.Items.Schema)
+	}
+	// map fields are objects with the value type in additionalProperties
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		result += " to " + typeOf(*schema.AdditionalProperties.Schema)
+	}
+	return result
+}
+
+func refOf(schema *spec.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	return schema.Ref.String()
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, v := range haystack {
+		if v == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the union of keys in the passed string-keyed maps, sorted.
+func sortedKeys(maps ...interface{}) []string {
+	seen := make(map[string]struct{})
+	for _, m := range maps {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			seen[key.String()] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for k := range seen {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,182 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+// document builds a swagger document with one rpc taking a Request,
+// and the given Request definition.
+func document(request spec.Schema) *spec.Swagger {
+	return &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Paths: &spec.Paths{
+				Paths: map[string]spec.PathItem{
+					"/twirp/pkg.Service/Get": {
+						PathItemProps: spec.PathItemProps{
+							Post: &spec.Operation{
+								OperationProps: spec.OperationProps{
+									Parameters: []spec.Parameter{
+										*spec.BodyParam("body", spec.RefSchema("#/definitions/pkg_Request")).AsRequired(),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Definitions: spec.Definitions{
+				"pkg_Request": request,
+			},
+		},
+	}
+}
+
+// respond sets the 200 response of the rpc in the document.
+func respond(sw *spec.Swagger, schema *spec.Schema) *spec.Swagger {
+	sw.Paths.Paths["/twirp/pkg.Service/Get"].Post.Responses = &spec.Responses{
+		ResponsesProps: spec.ResponsesProps{
+			StatusCodeResponses: map[int]spec.Response{
+				200: *spec.NewResponse().WithSchema(schema),
+			},
+		},
+	}
+	return sw
+}
+
+// embed makes the Request an allOf of pkg_Header and the properties,
+// as generated with -use_allof.
+func embed(properties map[string]spec.Schema) spec.Schema {
+	rest := *spec.MapProperty(nil)
+	rest.AdditionalProperties = nil
+	rest.Properties = properties
+	schema := spec.Schema{}
+	schema.AllOf = []spec.Schema{*spec.RefSchema("#/definitions/pkg_Header"), rest}
+	return schema
+}
+
+func TestCompare(t *testing.T) {
+	request := func(required []string, properties map[string]spec.Schema) spec.Schema {
+		schema := *spec.MapProperty(nil)
+		schema.AdditionalProperties = nil
+		schema.Properties = properties
+		schema.Required = required
+		return schema
+	}
+	name := *spec.StringProperty()
+	labels := *spec.MapProperty(spec.StringProperty())
+	counts := *spec.MapProperty(spec.Int64Property())
+	ids := *spec.ArrayProperty(spec.StringProperty())
+
+	testCases := []struct {
+		name   string
+		before *spec.Swagger
+		after  *spec.Swagger
+		want   []Change
+	}{
+		{
+			name:   "unchanged",
+			before: document(request(nil, map[string]spec.Schema{"name": name})),
+			after:  document(request(nil, map[string]spec.Schema{"name": name})),
+			want:   []Change{},
+		},
+		{
+			name:   "added optional field",
+			before: document(request(nil, map[string]spec.Schema{"name": name})),
+			after:  document(request(nil, map[string]spec.Schema{"name": name, "ids": ids})),
+			want:   []Change{{false, "added field pkg_Request.ids"}},
+		},
+		{
+			name:   "added required field",
+			before: document(request(nil, map[string]spec.Schema{"name": name})),
+			after:  document(request([]string{"ids"}, map[string]spec.Schema{"name": name, "ids": ids})),
+			want:   []Change{{true, "added field pkg_Request.ids"}},
+		},
+		{
+			name:   "removed field",
+			before: document(request(nil, map[string]spec.Schema{"name": name, "ids": ids})),
+			after:  document(request(nil, map[string]spec.Schema{"name": name})),
+			want:   []Change{{true, "removed field pkg_Request.ids"}},
+		},
+		{
+			name:   "field now required",
+			before: document(request(nil, map[string]spec.Schema{"name": name})),
+			after:  document(request([]string{"name"}, map[string]spec.Schema{"name": name})),
+			want:   []Change{{true, "changed field pkg_Request.name, now required"}},
+		},
+		{
+			name:   "field no longer required",
+			before: document(request([]string{"name"}, map[string]spec.Schema{"name": name})),
+			after:  document(request(nil, map[string]spec.Schema{"name": name})),
+			want:   []Change{{false, "changed field pkg_Request.name, no longer required"}},
+		},
+		{
+			name:   "changed field type",
+			before: document(request(nil, map[string]spec.Schema{"name": name})),
+			after:  document(request(nil, map[string]spec.Schema{"name": ids})),
+			want:   []Change{{true, "changed field pkg_Request.name, type [string] -> [array] of [string]"}},
+		},
+		{
+			name:   "changed map value type",
+			before: document(request(nil, map[string]spec.Schema{"labels": labels})),
+			after:  document(request(nil, map[string]spec.Schema{"labels": counts})),
+			want:   []Change{{true, "changed field pkg_Request.labels, type [object] to [string] -> [object] to [integer]/int64"}},
+		},
+		{
+			name:   "changed response",
+			before: respond(document(request(nil, nil)), spec.RefSchema("#/definitions/pkg_Response")),
+			after:  respond(document(request(nil, nil)), spec.RefSchema("#/definitions/pkg_Other")),
+			want:   []Change{{true, "changed response 200 on /twirp/pkg.Service/Get, schema #/definitions/pkg_Response -> #/definitions/pkg_Other"}},
+		},
+		{
+			name:   "removed response",
+			before: respond(document(request(nil, nil)), spec.RefSchema("#/definitions/pkg_Response")),
+			after:  document(request(nil, nil)),
+			want:   []Change{{true, "removed response 200 from /twirp/pkg.Service/Get"}},
+		},
+		{
+			name:   "added response",
+			before: document(request(nil, nil)),
+			after:  respond(document(request(nil, nil)), spec.RefSchema("#/definitions/pkg_Response")),
+			want:   []Change{{false, "added response 200 to /twirp/pkg.Service/Get"}},
+		},
+		{
+			name:   "removed allOf field",
+			before: document(embed(map[string]spec.Schema{"name": name, "ids": ids})),
+			after:  document(embed(map[string]spec.Schema{"name": name})),
+			want:   []Change{{true, "removed field pkg_Request.ids"}},
+		},
+		{
+			name:   "removed allOf ref",
+			before: document(embed(map[string]spec.Schema{"name": name})),
+			after:  document(request(nil, map[string]spec.Schema{"name": name})),
+			want:   []Change{{true, "removed allOf #/definitions/pkg_Header from pkg_Request"}},
+		},
+		{
+			name:   "removed path",
+			before: document(request(nil, nil)),
+			after: &spec.Swagger{SwaggerProps: spec.SwaggerProps{
+				Definitions: spec.Definitions{"pkg_Request": request(nil, nil)},
+			}},
+			want: []Change{{true, "removed path /twirp/pkg.Service/Get"}},
+		},
+		{
+			name:   "removed definition",
+			before: document(request(nil, nil)),
+			after:  &spec.Swagger{SwaggerProps: spec.SwaggerProps{Paths: document(request(nil, nil)).Paths}},
+			want:   []Change{{true, "removed definition pkg_Request"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		got := Compare(tc.before, tc.after)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		if HasBreaking(got) != HasBreaking(tc.want) {
+			t.Errorf("%s: got breaking %t", tc.name, HasBreaking(got))
+		}
+	}
+}
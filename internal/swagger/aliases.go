@@ -1,7 +1,10 @@
 package swagger
 
+// typeAliases maps proto scalar and well known types to their schema
+// type and format. The description is used for fields which don't have
+// their own, to document the encoding of the value.
 var typeAliases = map[string]struct {
-	Type, Format string
+	Type, Format, Description string
 }{
 	// proto numeric types
 	"int32":    {Type: "integer", Format: "int32"},
@@ -33,11 +36,13 @@ var typeAliases = map[string]struct {
 	},
 
 	"google.protobuf.Timestamp": {
-		Type:   "string",
-		Format: "date-time",
+		Type:        "string",
+		Format:      "date-time",
+		Description: "[RFC 3339](https://www.ietf.org/rfc/rfc3339.txt) date-time in UTC, e.g. 2017-01-15T01:30:15.01Z",
 	},
 	"google.protobuf.Duration": {
-		Type: "string",
+		Type:        "string",
+		Description: "Duration in seconds with up to nine fractional digits and an `s` suffix, e.g. 1.5s",
 	},
 	"google.protobuf.StringValue": {
 		Type: "string",
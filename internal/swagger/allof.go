@@ -0,0 +1,94 @@
+package swagger
+
+import (
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// embeddedField returns the name of the only property referencing a
+// message definition. Enums and repeated or map fields don't count.
+func (sw *Writer) embeddedField(schema *spec.Schema) (string, bool) {
+	result := ""
+	for name, property := range schema.Properties {
+		ref := property.Ref.String()
+		if ref == "" {
+			continue
+		}
+		if _, ok := sw.enums[strings.TrimPrefix(ref, "#/definitions/")]; ok {
+			continue
+		}
+		if result != "" {
+			return "", false
+		}
+		result = name
+	}
+	return result, result != ""
+}
+
+// composeAllOf rewrites a message with a single embedded message field,
+// e.g. `Header header = 1`, into an allOf of the embedded message and
+// the remaining fields, which code generators read as type extension.
+func (sw *Writer) composeAllOf(schema *spec.Schema) {
+	name, ok := sw.embeddedField(schema)
+	if !ok {
+		return
+	}
+	embedded := schema.Properties[name]
+
+	properties := make(map[string]spec.Schema)
+	for key, property := range schema.Properties {
+		if key != name {
+			properties[key] = property
+		}
+	}
+	required := []string{}
+	for _, key := range schema.Required {
+		if key != name {
+			required = append(required, key)
+		}
+	}
+
+	schema.AllOf = []spec.Schema{
+		{
+			SchemaProps: spec.SchemaProps{
+				Ref: embedded.Ref,
+			},
+		},
+	}
+	if len(properties) > 0 {
+		rest := spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:       spec.StringOrArray([]string{"object"}),
+				Properties: properties,
+			},
+		}
+		if len(required) > 0 {
+			rest.Required = required
+		}
+		schema.AllOf = append(schema.AllOf, rest)
+	}
+	schema.Properties = nil
+	schema.Required = nil
+}
+
+// wrapRefs moves the ref of properties with a title or description
+// into an allOf. Swagger 2.0 ignores the siblings of a $ref, so most
+// renderers drop the field documentation, while siblings of an allOf
+// are kept.
+func wrapRefs(schema *spec.Schema) {
+	for name, property := range schema.Properties {
+		if property.Ref.String() == "" || (property.Title == "" && property.Description == "") {
+			continue
+		}
+		property.AllOf = []spec.Schema{
+			{
+				SchemaProps: spec.SchemaProps{
+					Ref: property.Ref,
+				},
+			},
+		}
+		property.Ref = spec.Ref{}
+		schema.Properties[name] = property
+	}
+}
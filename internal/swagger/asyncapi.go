@@ -0,0 +1,66 @@
+package swagger
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-bridget/twirp-swagger-gen/internal/asyncapi"
+)
+
+// streamingRPC keeps the details of a streaming rpc for GetAsyncAPI.
+type streamingRPC struct {
+	pathName string
+	id       string
+	summary  string
+
+	request, response string
+}
+
+// HasStreams returns true if any of the walked rpcs are streaming.
+func (sw *Writer) HasStreams() bool {
+	return len(sw.streams) > 0
+}
+
+// GetAsyncAPI returns an AsyncAPI document, where each streaming rpc
+// is a channel addressed by the twirp path. Message schemas are the
+// swagger definitions, with refs pointing to the document components.
+func (sw *Writer) GetAsyncAPI() []byte {
+	doc := asyncapi.Document{
+		AsyncAPI: asyncapi.Version,
+		Info: asyncapi.Info{
+			Title:   sw.Info.Title,
+			Version: sw.Info.Version,
+		},
+		Channels: make(map[string]asyncapi.Channel),
+		Components: &asyncapi.Components{
+			Schemas: make(map[string]json.RawMessage),
+		},
+	}
+
+	for _, stream := range sw.streams {
+		doc.Channels[stream.pathName] = asyncapi.Channel{
+			Description: stream.summary,
+			Publish: &asyncapi.Operation{
+				OperationID: stream.id + "Request",
+				Message: asyncapi.Message{
+					Payload: asyncapi.SchemaRef(stream.request),
+				},
+			},
+			Subscribe: &asyncapi.Operation{
+				OperationID: stream.id + "Response",
+				Message: asyncapi.Message{
+					Payload: asyncapi.SchemaRef(stream.response),
+				},
+			},
+		}
+	}
+
+	for name, schema := range sw.Swagger.Definitions {
+		b, _ := json.Marshal(schema)
+		b = []byte(strings.ReplaceAll(string(b), `"#/definitions/`, `"#/components/schemas/`))
+		doc.Components.Schemas[name] = b
+	}
+
+	b, _ := json.MarshalIndent(doc, "", "  ")
+	return b
+}
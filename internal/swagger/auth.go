@@ -0,0 +1,21 @@
+package swagger
+
+// applyAuth drops the security requirements of the document and the
+// operations if auth isn't required, and with noAuth the security
+// definitions as well. The writer doesn't define any security itself,
+// they come from the overlay, the gateway options and the
+// `unauthenticated` tag.
+func (sw *Writer) applyAuth() {
+	if sw.authRequired && !sw.noAuth {
+		return
+	}
+	if sw.noAuth {
+		sw.Swagger.SecurityDefinitions = nil
+	}
+	sw.Swagger.Security = nil
+	for _, pathName := range sortedPaths(sw.Swagger.Paths) {
+		if operation := sw.Swagger.Paths.Paths[pathName].Post; operation != nil {
+			operation.Security = nil
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package swagger
+
+import (
+	"sync"
+
+	"github.com/emicklei/proto"
+)
+
+// ParseCache holds parsed proto files by their resolved path. Each
+// writer has its own cache by default, and writers for several inputs
+// can share one WithParseCache, so common imports are only parsed once
+// per run. It's safe for concurrent use.
+type ParseCache struct {
+	mu    sync.RWMutex
+	files map[string]*proto.Proto
+}
+
+// NewParseCache creates an empty cache.
+func NewParseCache() *ParseCache {
+	return &ParseCache{
+		files: make(map[string]*proto.Proto),
+	}
+}
+
+func (c *ParseCache) get(filename string) (*proto.Proto, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	definition, ok := c.files[filename]
+	return definition, ok
+}
+
+func (c *ParseCache) put(filename string, definition *proto.Proto) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[filename] = definition
+}
@@ -0,0 +1,64 @@
+package swagger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+var ErrCollision = errors.New("conflicting definitions or paths")
+
+// Combine merges the documents generated for several files into one.
+// Definitions which are the same in several documents, like messages
+// from a common import, are kept once. Different definitions or paths
+// with the same name are an ErrCollision error. The info, host and the
+// other top level fields are taken from the first document.
+func Combine(documents ...*spec.Swagger) (*spec.Swagger, error) {
+	if len(documents) == 0 {
+		return nil, errors.New("no documents to combine")
+	}
+
+	result := *documents[0]
+	result.Definitions = make(spec.Definitions)
+	result.Paths = &spec.Paths{
+		Paths: make(map[string]spec.PathItem),
+	}
+	result.Tags = nil
+
+	problems := []string{}
+	tags := make(map[string]bool)
+	for _, document := range documents {
+		for name, schema := range document.Definitions {
+			if existing, ok := result.Definitions[name]; ok && !reflect.DeepEqual(existing, schema) {
+				problems = append(problems, "definition "+name)
+				continue
+			}
+			result.Definitions[name] = schema
+		}
+		if document.Paths != nil {
+			for name, item := range document.Paths.Paths {
+				if existing, ok := result.Paths.Paths[name]; ok && !reflect.DeepEqual(existing, item) {
+					problems = append(problems, "path "+name)
+					continue
+				}
+				result.Paths.Paths[name] = item
+			}
+		}
+		for _, tag := range document.Tags {
+			if !tags[tag.Name] {
+				tags[tag.Name] = true
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%w: %s", ErrCollision, strings.Join(problems, "; "))
+	}
+	return &result, nil
+}
@@ -0,0 +1,56 @@
+package swagger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func walkDocument(t *testing.T, filename string, opts ...WriterOption) *spec.Swagger {
+	t.Helper()
+	writer := NewWriter(filename, "api.example.com", "/twirp", opts...)
+	if err := writer.WalkFile(); err != nil && !errors.Is(err, ErrNoServiceDefinition) {
+		t.Fatal(err)
+	}
+	return writer.Swagger
+}
+
+func TestCombine(t *testing.T) {
+	combined, err := Combine(
+		walkDocument(t, "testdata/diamond.proto"),
+		walkDocument(t, "testdata/diamond_left.proto"),
+		walkDocument(t, "testdata/map_fields.proto"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"/twirp/diamond.Shapes/Get", "/twirp/maps.MapService/Get"} {
+		if _, ok := combined.Paths.Paths[name]; !ok {
+			t.Errorf("missing path %s", name)
+		}
+	}
+	for _, name := range []string{"base_Meta", "left_Request", "maps_Item"} {
+		if _, ok := combined.Definitions[name]; !ok {
+			t.Errorf("missing definition %s", name)
+		}
+	}
+	if len(combined.Tags) != 2 {
+		t.Errorf("got tags %v, want Shapes and MapService", combined.Tags)
+	}
+}
+
+func TestCombine_Collision(t *testing.T) {
+	_, err := Combine(
+		walkDocument(t, "testdata/map_fields.proto"),
+		walkDocument(t, "testdata/map_fields.proto", WithStrictObjects(true)),
+	)
+	if !errors.Is(err, ErrCollision) {
+		t.Fatalf("got error %v, want ErrCollision", err)
+	}
+	if !strings.Contains(err.Error(), "definition maps_Item") {
+		t.Errorf("error doesn't mention maps_Item: %s", err)
+	}
+}
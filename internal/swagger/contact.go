@@ -0,0 +1,71 @@
+package swagger
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+
+	"github.com/go-openapi/spec"
+)
+
+// validateEmail checks for a plain email address with a domain,
+// like `api@example.com`, without a display name.
+func validateEmail(s string) error {
+	address, err := mail.ParseAddress(s)
+	if err != nil {
+		return fmt.Errorf("invalid email %q: %w", s, err)
+	}
+	if address.Name != "" || address.Address != s {
+		return fmt.Errorf("invalid email %q: want a plain address like api@example.com", s)
+	}
+	return nil
+}
+
+// validateURL checks for an absolute URL, like `https://example.com/terms`.
+func validateURL(s string) error {
+	u, err := url.ParseRequestURI(s)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", s, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid url %q: want an absolute url like https://example.com", s)
+	}
+	return nil
+}
+
+// validateContact checks the contact info set with WithContact and
+// WithTermsOfService, unset values are skipped.
+func (sw *Writer) validateContact() error {
+	if sw.contact != nil {
+		if sw.contact.Email != "" {
+			if err := validateEmail(sw.contact.Email); err != nil {
+				return fmt.Errorf("contact email: %w", err)
+			}
+		}
+		if sw.contact.URL != "" {
+			if err := validateURL(sw.contact.URL); err != nil {
+				return fmt.Errorf("contact url: %w", err)
+			}
+		}
+	}
+	if sw.termsOfService != "" {
+		if err := validateURL(sw.termsOfService); err != nil {
+			return fmt.Errorf("terms of service: %w", err)
+		}
+	}
+	return nil
+}
+
+// contactInfo returns the contact info, or nil if none was set.
+func contactInfo(name, email, url string) *spec.ContactInfo {
+	if name == "" && email == "" && url == "" {
+		return nil
+	}
+	return &spec.ContactInfo{
+		ContactInfoProps: spec.ContactInfoProps{
+			Name:  name,
+			Email: email,
+			URL:   url,
+		},
+	}
+}
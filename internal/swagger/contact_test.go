@@ -0,0 +1,45 @@
+package swagger
+
+import "testing"
+
+func TestValidateEmail(t *testing.T) {
+	testCases := []struct {
+		in    string
+		valid bool
+	}{
+		{"api@example.com", true},
+		{"api.team+docs@mail.example.com", true},
+		{"api", false},
+		{"api@", false},
+		{"@example.com", false},
+		{"API Team <api@example.com>", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if err := validateEmail(tc.in); (err == nil) != tc.valid {
+			t.Errorf("%q: got error %v, want valid %t", tc.in, err, tc.valid)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	testCases := []struct {
+		in    string
+		valid bool
+	}{
+		{"https://example.com", true},
+		{"https://example.com/terms?lang=en", true},
+		{"http://localhost:8080/docs", true},
+		{"example.com/terms", false},
+		{"/terms", false},
+		{"https://", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if err := validateURL(tc.in); (err == nil) != tc.valid {
+			t.Errorf("%q: got error %v, want valid %t", tc.in, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package swagger
+
+import (
+	"github.com/emicklei/proto"
+	"github.com/go-openapi/spec"
+)
+
+// collectEnums records the value names of the enums in a proto file,
+// including nested enums, by definition name. The enums are collected
+// before the messages are walked, so fields can reference enums which
+// are declared further down the file. Reserved names are recorded for
+// documentation, reserved numbers have no use in the string values.
+func (sw *Writer) collectEnums(definition *proto.Proto) {
+	withPackage := func(pkg *proto.Package) {
+		sw.packageName = pkg.Name
+	}
+	withEnum := func(enum *proto.Enum) {
+		values := []string{}
+		reserved := []string{}
+		for _, element := range enum.Elements {
+			switch val := element.(type) {
+			case *proto.EnumField:
+				values = append(values, val.Name)
+			case *proto.Reserved:
+				reserved = append(reserved, val.FieldNames...)
+			}
+		}
+		name := sw.definitionName(enum.Name)
+		sw.enums[name] = values
+		if len(reserved) > 0 {
+			sw.enumReserved[name] = reserved
+		}
+	}
+	proto.Walk(definition, proto.WithPackage(withPackage), proto.WithEnum(withEnum))
+}
+
+// Enum adds a string definition listing the enum value names, as
+// enums are serialized by name in JSON. With inline enums the fields
+// list the values, and the definitions are left out.
+func (sw *Writer) Enum(enum *proto.Enum) {
+	if sw.inlineEnums {
+		return
+	}
+	definitionName := sw.definitionName(enum.Name)
+	if !sw.includePackage() {
+		sw.filtered[definitionName] = true
+		return
+	}
+	if sw.skipWellKnown && sw.packageName == "google.protobuf" {
+		sw.skippedWellKnown++
+		return
+	}
+
+	schema, ok := sw.inlineEnum(enum.Name)
+	if !ok {
+		return
+	}
+	schema.Title = comment(enum.Comment)
+	if schema.Title == "" && sw.autoTitles {
+		schema.Title = humanName(enum.Name)
+	}
+	schema.Description = description(enum.Comment)
+	schema.AddExtension("x-proto-file", sw.protoFile())
+	if sw.emitSourceInfo {
+		schema.AddExtension("x-proto-source", sw.source(enum.Position))
+	}
+	if sw.definitionOrder {
+		schema.AddExtension("x-order", sw.definitionCount)
+		sw.definitionCount++
+	}
+
+	sw.Swagger.Definitions[definitionName] = schema
+}
+
+// enumExample checks that the example of an enum field is one of the
+// values, or a list of them for repeated fields. The first invalid
+// value is returned.
+func enumExample(example interface{}, values []string) (interface{}, bool) {
+	if list, ok := example.([]interface{}); ok {
+		for _, item := range list {
+			if invalid, ok := enumExample(item, values); !ok {
+				return invalid, false
+			}
+		}
+		return nil, true
+	}
+	for _, value := range values {
+		if example == value {
+			return nil, true
+		}
+	}
+	return example, false
+}
+
+// inlineEnum returns a string schema listing the enum values, if the
+// field type is a known enum.
+func (sw *Writer) inlineEnum(fieldType string) (spec.Schema, bool) {
+	values, ok := sw.enums[sw.definitionName(fieldType)]
+	if !ok {
+		return spec.Schema{}, false
+	}
+	enum := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		enum = append(enum, value)
+	}
+	schema := spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type: spec.StringOrArray([]string{"string"}),
+			Enum: enum,
+		},
+	}
+	if reserved, ok := sw.enumReserved[sw.definitionName(fieldType)]; ok {
+		schema.AddExtension("x-enum-reserved", reserved)
+	}
+	return schema, true
+}
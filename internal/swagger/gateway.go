@@ -0,0 +1,133 @@
+package swagger
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/emicklei/proto"
+	"github.com/go-openapi/spec"
+)
+
+// Options from grpc-gateway's protoc-gen-openapiv2, which are read when
+// the writer is created WithGatewayOptions. This eases migration from
+// the grpc-gateway generator.
+const (
+	gatewayOperationOption = "grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation"
+	gatewaySchemaOption    = "grpc.gateway.protoc_gen_openapiv2.options.openapiv2_schema"
+)
+
+// messageOptions collects the options declared in the message body.
+func messageOptions(msg *proto.Message) []*proto.Option {
+	result := []*proto.Option{}
+	for _, element := range msg.Elements {
+		if option, ok := element.(*proto.Option); ok {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// literalValues returns the values of a literal, which is either a
+// single value or an array.
+func literalValues(literal *proto.Literal) []string {
+	if len(literal.Array) == 0 {
+		return []string{literal.Source}
+	}
+	result := make([]string, 0, len(literal.Array))
+	for _, item := range literal.Array {
+		result = append(result, item.Source)
+	}
+	return result
+}
+
+// applyGatewayOperation maps an openapiv2_operation option onto the operation.
+func (sw *Writer) applyGatewayOperation(operation *spec.Operation, options []*proto.Option) {
+	option, ok := findOption(options, gatewayOperationOption)
+	if !ok {
+		return
+	}
+
+	tags := []string{}
+	for _, field := range option.Constant.OrderedMap {
+		switch field.Name {
+		case "summary":
+			operation.Summary = field.Source
+		case "description":
+			operation.Description = field.Source
+		case "operation_id":
+			operation.ID = field.Source
+		case "deprecated":
+			operation.Deprecated = field.Source == "true"
+		case "tags":
+			tags = append(tags, literalValues(field.Literal)...)
+		case "security":
+			operation.Security = append(operation.Security, gatewaySecurity(field.Literal))
+		default:
+			sw.logger().Debug("unsupported field", "option", gatewayOperationOption, "field", field.Name)
+		}
+	}
+
+	if len(tags) > 0 {
+		operation.Tags = tags
+		for _, name := range tags {
+			if !sw.hasTag(name) {
+				sw.Swagger.Tags = append(sw.Swagger.Tags, spec.NewTag(name, "", nil))
+			}
+		}
+	}
+}
+
+// gatewaySecurity reads a SecurityRequirement literal, e.g.
+// `{security_requirement: {key: "OAuth2" value: {scope: "read"}}}`.
+func gatewaySecurity(literal *proto.Literal) map[string][]string {
+	result := make(map[string][]string)
+	for _, requirement := range literal.OrderedMap {
+		if requirement.Name != "security_requirement" {
+			continue
+		}
+		key, _ := requirement.OrderedMap.Get("key")
+		scopes := []string{}
+		if value, ok := requirement.OrderedMap.Get("value"); ok {
+			for _, scope := range value.OrderedMap {
+				if scope.Name == "scope" {
+					scopes = append(scopes, literalValues(scope.Literal)...)
+				}
+			}
+		}
+		result[key.Source] = scopes
+	}
+	return result
+}
+
+// applyGatewaySchema maps an openapiv2_schema option onto the schema.
+func (sw *Writer) applyGatewaySchema(schema *spec.Schema, options []*proto.Option) {
+	option, ok := findOption(options, gatewaySchemaOption)
+	if !ok {
+		return
+	}
+
+	for _, field := range option.Constant.OrderedMap {
+		switch field.Name {
+		case "json_schema":
+			if title, ok := field.OrderedMap.Get("title"); ok {
+				schema.Title = title.Source
+			}
+			if description, ok := field.OrderedMap.Get("description"); ok {
+				schema.Description = description.Source
+			}
+		case "example":
+			source := field.Source
+			if unquoted, err := strconv.Unquote(`"` + source + `"`); err == nil {
+				source = unquoted
+			}
+			var example interface{}
+			if err := json.Unmarshal([]byte(source), &example); err != nil {
+				sw.logger().Warn("ignoring invalid example", "option", gatewaySchemaOption, "error", err)
+				continue
+			}
+			schema.Example = example
+		default:
+			sw.logger().Debug("unsupported field", "option", gatewaySchemaOption, "field", field.Name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package swagger
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// generatorName is the tool name recorded in the `x-generator` extension.
+const generatorName = "twirp-swagger-gen"
+
+// generatorVersion returns the version set WithGeneratorVersion, or
+// the module version of the running binary, which is `(devel)` for
+// local builds.
+func (sw *Writer) generatorVersion() string {
+	if sw.binaryVersion != "" {
+		return sw.binaryVersion
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	return info.Main.Version
+}
+
+// generatorExtension returns the `x-generator` extension value.
+func (sw *Writer) generatorExtension() map[string]string {
+	return map[string]string{
+		"name":       generatorName,
+		"version":    sw.generatorVersion(),
+		"apiVersion": sw.version,
+		"input":      sw.filename,
+	}
+}
+
+// generatedByExtension returns the top level `x-generated-by` extension
+// value. The timestamp makes the output differ between runs.
+func (sw *Writer) generatedByExtension() map[string]string {
+	return map[string]string{
+		"tool":       generatorName,
+		"version":    sw.generatorVersion(),
+		"proto_file": sw.filename,
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+	}
+}
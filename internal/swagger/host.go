@@ -0,0 +1,31 @@
+package swagger
+
+import "strings"
+
+// splitHost separates a scheme and a path from the host name, which
+// swagger 2.0 keeps in `schemes` and `basePath`. For example the host
+// `https://api.example.com/v1` returns `api.example.com`, `https`
+// and `/v1`.
+func splitHost(hostname string) (host, scheme, basePath string) {
+	host = hostname
+	if idx := strings.Index(host, "://"); idx >= 0 {
+		scheme, host = strings.ToLower(host[:idx]), host[idx+3:]
+	}
+	if idx := strings.Index(host, "/"); idx >= 0 {
+		host, basePath = host[:idx], strings.TrimRight(host[idx:], "/")
+	}
+	return host, scheme, basePath
+}
+
+// setHost sets the host name, moving any scheme and path to the
+// schemes and base path with a warning.
+func (sw *Writer) setHost(hostname string) {
+	host, scheme, basePath := splitHost(hostname)
+	if scheme != "" {
+		sw.logger().Warn("host contains a scheme, moving it to schemes", "host", hostname, "scheme", scheme)
+	}
+	if basePath != "" {
+		sw.logger().Warn("host contains a path, moving it to the base path", "host", hostname, "basePath", basePath)
+	}
+	sw.hostname, sw.scheme, sw.basePath = host, scheme, basePath
+}
@@ -0,0 +1,45 @@
+package swagger
+
+import "testing"
+
+func TestSplitHost(t *testing.T) {
+	testCases := []struct {
+		in                     string
+		host, scheme, basePath string
+	}{
+		{"api.example.com", "api.example.com", "", ""},
+		{"api.example.com:8080", "api.example.com:8080", "", ""},
+		{"https://api.example.com", "api.example.com", "https", ""},
+		{"HTTP://api.example.com/", "api.example.com", "http", ""},
+		{"https://api.example.com/v1", "api.example.com", "https", "/v1"},
+		{"api.example.com/api/v1/", "api.example.com", "", "/api/v1"},
+	}
+
+	for _, tc := range testCases {
+		host, scheme, basePath := splitHost(tc.in)
+		if host != tc.host || scheme != tc.scheme || basePath != tc.basePath {
+			t.Errorf("%q: got (%q, %q, %q), want (%q, %q, %q)", tc.in, host, scheme, basePath, tc.host, tc.scheme, tc.basePath)
+		}
+	}
+}
+
+func TestWriter_HostWarningsReport(t *testing.T) {
+	report := NewReport()
+	writer := NewWriter("testdata/simple_service.proto", "https://api.example.com/v1", "/twirp", WithReport(report))
+	if writer.hostname != "api.example.com" {
+		t.Errorf("got host %q, want api.example.com", writer.hostname)
+	}
+
+	want := []string{
+		"host contains a scheme, moving it to schemes",
+		"host contains a path, moving it to the base path",
+	}
+	if len(report.Warnings) != len(want) {
+		t.Fatalf("got warnings %+v, want %d", report.Warnings, len(want))
+	}
+	for k, warning := range report.Warnings {
+		if warning.Message != want[k] || warning.Fields["file"] != "testdata/simple_service.proto" {
+			t.Errorf("got warning %+v, want %q", warning, want[k])
+		}
+	}
+}
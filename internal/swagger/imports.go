@@ -0,0 +1,128 @@
+package swagger
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/proto"
+)
+
+// skipImport reports if the imported file isn't walked for messages.
+func skipImport(filename string) bool {
+	// the exclusion here is more about path traversal than it is
+	// about the structure of google proto messages. The annotations
+	// could serve to document a REST API, which goes beyond what
+	// Twitch RPC does out of the box.
+	if strings.Contains(filename, "google/api/annotations.proto") {
+		return true
+	}
+
+	// the options are read from the file, it has no messages
+	if path.Base(filename) == "twirp_swagger.proto" {
+		return true
+	}
+
+	// timestamps are handled as string of date-time
+	return strings.Contains(filename, "google/protobuf/timestamp.proto")
+}
+
+// resolveImport returns the path of an imported file, searching the
+// proto paths in order like protoc's -I. Without proto paths, or if
+// the file isn't found, imports are relative to the working directory.
+func (sw *Writer) resolveImport(filename string) string {
+	for _, dir := range sw.protoPaths {
+		candidate := filepath.Join(dir, filename)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return filename
+}
+
+// Files returns the paths of the proto files walked, the main file
+// and the resolved imports, sorted. Imports which couldn't be loaded
+// are included, so watchers notice when they're created.
+func (sw *Writer) Files() []string {
+	result := make([]string, 0, len(sw.imported))
+	for filename := range sw.imported {
+		result = append(result, filename)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// protoFile returns the current file as its import name, relative to
+// the proto path containing it, so x-proto-file doesn't depend on the
+// working directory. Imports already use their import name, the main
+// file is given as a path.
+func (sw *Writer) protoFile() string {
+	if sw.currentFile != sw.filename {
+		return sw.currentFile
+	}
+	filename := filepath.Clean(sw.filename)
+	for _, dir := range sw.protoPaths {
+		if rel, err := filepath.Rel(dir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(filename)
+}
+
+// importFilenames returns the files imported by definition.
+func importFilenames(definition *proto.Proto) []string {
+	result := []string{}
+	for _, element := range definition.Elements {
+		if i, ok := element.(*proto.Import); ok && !skipImport(i.Filename) {
+			result = append(result, i.Filename)
+		}
+	}
+	return result
+}
+
+// parseImports parses the files imported by definition, and the files
+// they import in turn, into the cache, with up to sw.parallelImports
+// files parsed at the same time. Walking the parsed files stays
+// sequential in Import, as the package name tracking depends on the
+// import order.
+func (sw *Writer) parseImports(definition *proto.Proto) {
+	seen := make(map[string]bool)
+	limit := make(chan struct{}, sw.parallelImports)
+
+	pending := importFilenames(definition)
+	for len(pending) > 0 {
+		var wg sync.WaitGroup
+		results := make(chan *proto.Proto, len(pending))
+		for _, filename := range pending {
+			if seen[filename] {
+				continue
+			}
+			seen[filename] = true
+
+			wg.Add(1)
+			go func(filename string) {
+				defer wg.Done()
+				limit <- struct{}{}
+				defer func() { <-limit }()
+
+				// errors are logged by Import, which tries to
+				// load the file again when it's walked
+				definition, err := sw.loadProtoFile(sw.resolveImport(filename))
+				if err != nil {
+					return
+				}
+				results <- definition
+			}(filename)
+		}
+		wg.Wait()
+		close(results)
+
+		pending = nil
+		for definition := range results {
+			pending = append(pending, importFilenames(definition)...)
+		}
+	}
+}
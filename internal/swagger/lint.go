@@ -0,0 +1,222 @@
+package swagger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+var ErrLint = errors.New("swagger document failed lint")
+
+// validTypes lists the schema types allowed by swagger 2.0.
+var validTypes = map[string]bool{
+	"array":   true,
+	"boolean": true,
+	"integer": true,
+	"number":  true,
+	"object":  true,
+	"string":  true,
+	"file":    true,
+}
+
+// Lint checks the generated document for a subset of the swagger 2.0
+// rules: dangling refs, invalid types, arrays without items, duplicate
+// operation IDs, duplicate items in the schemes, consumes, produces and
+// tags lists, security requirements without a security definition, and
+// a missing info title or version. It's not a full validator, a document
+// which passes Lint may still be rejected by go-openapi/validate.
+func (sw *Writer) Lint() error {
+	problems := []string{}
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if sw.Info == nil || sw.Info.Title == "" || sw.Info.Version == "" {
+		addProblem("info: title and version are required")
+	}
+	uniqueItems("schemes", sw.Schemes, addProblem)
+	uniqueItems("consumes", sw.Consumes, addProblem)
+	uniqueItems("produces", sw.Produces, addProblem)
+	tagNames := make([]string, 0, len(sw.Swagger.Tags))
+	for _, tag := range sw.Swagger.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	uniqueItems("tags", tagNames, addProblem)
+	sw.lintSecurity("security", sw.Swagger.Security, addProblem)
+
+	operationIDs := make(map[string]string)
+	for _, pathName := range sortedPaths(sw.Swagger.Paths) {
+		operation := sw.Swagger.Paths.Paths[pathName].Post
+		if operation == nil {
+			continue
+		}
+		if other, ok := operationIDs[operation.ID]; ok {
+			addProblem("duplicate operationId %q in %s and %s", operation.ID, other, pathName)
+		}
+		operationIDs[operation.ID] = pathName
+
+		uniqueItems(pathName+" schemes", operation.Schemes, addProblem)
+		uniqueItems(pathName+" consumes", operation.Consumes, addProblem)
+		uniqueItems(pathName+" produces", operation.Produces, addProblem)
+		uniqueItems(pathName+" tags", operation.Tags, addProblem)
+		sw.lintSecurity(pathName+" security", operation.Security, addProblem)
+	}
+
+	sw.walkSchemas(func(location string, schema *spec.Schema) {
+		sw.lintSchema(location, schema, addProblem)
+	})
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrLint, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// uniqueItems checks that a list has no duplicates, as swagger 2.0
+// requires unique items for the schemes, mime types and tags.
+func uniqueItems(location string, items []string, addProblem func(string, ...interface{})) {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if seen[item] {
+			addProblem("%s: duplicate item %q", location, item)
+		}
+		seen[item] = true
+	}
+}
+
+// lintSecurity checks that the security requirements refer to a
+// security definition.
+func (sw *Writer) lintSecurity(location string, requirements []map[string][]string, addProblem func(string, ...interface{})) {
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := sw.SecurityDefinitions[name]; !ok {
+				addProblem("%s: undefined security definition %q", location, name)
+			}
+		}
+	}
+}
+
+// lintSchema checks a single schema, walkSchemas visits the
+// nested schemas.
+func (sw *Writer) lintSchema(location string, schema *spec.Schema, addProblem func(string, ...interface{})) {
+	if ref, ok := sw.unresolvedRef(schema); ok {
+		addProblem("%s: unresolved ref %q", location, ref)
+	}
+	for _, t := range schema.Type {
+		if !validTypes[t] {
+			addProblem("%s: invalid type %q", location, t)
+		}
+	}
+	if schema.Type.Contains("array") && (schema.Items == nil || schema.Items.Schema == nil) {
+		addProblem("%s: array without items", location)
+	}
+}
+
+// unresolvedRef returns the ref of a schema if it doesn't point to
+// one of the definitions.
+func (sw *Writer) unresolvedRef(schema *spec.Schema) (string, bool) {
+	ref := schema.Ref.String()
+	if ref == "" {
+		return "", false
+	}
+	name := strings.TrimPrefix(ref, "#/definitions/")
+	if _, ok := sw.Swagger.Definitions[name]; !ok || name == ref {
+		return ref, true
+	}
+	return "", false
+}
+
+// walkSchemas calls visit for the operation parameter and response
+// schemas, the shared responses, the definitions, and all the schemas
+// nested in them, in a stable order.
+func (sw *Writer) walkSchemas(visit func(location string, schema *spec.Schema)) {
+	for _, pathName := range sortedPaths(sw.Swagger.Paths) {
+		operation := sw.Swagger.Paths.Paths[pathName].Post
+		if operation == nil {
+			continue
+		}
+		for _, param := range operation.Parameters {
+			walkSchema(pathName+" parameter "+param.Name, param.Schema, visit)
+		}
+		if operation.Responses != nil {
+			codes := make([]int, 0, len(operation.Responses.StatusCodeResponses))
+			for code := range operation.Responses.StatusCodeResponses {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			for _, code := range codes {
+				response := operation.Responses.StatusCodeResponses[code]
+				walkSchema(fmt.Sprintf("%s response %d", pathName, code), response.Schema, visit)
+			}
+			if operation.Responses.Default != nil {
+				walkSchema(pathName+" response default", operation.Responses.Default.Schema, visit)
+			}
+		}
+	}
+
+	responseNames := make([]string, 0, len(sw.Swagger.Responses))
+	for name := range sw.Swagger.Responses {
+		responseNames = append(responseNames, name)
+	}
+	sort.Strings(responseNames)
+	for _, name := range responseNames {
+		walkSchema("response "+name, sw.Swagger.Responses[name].Schema, visit)
+	}
+
+	definitionNames := make([]string, 0, len(sw.Swagger.Definitions))
+	for name := range sw.Swagger.Definitions {
+		definitionNames = append(definitionNames, name)
+	}
+	sort.Strings(definitionNames)
+	for _, name := range definitionNames {
+		schema := sw.Swagger.Definitions[name]
+		walkSchema("definition "+name, &schema, visit)
+	}
+}
+
+func walkSchema(location string, schema *spec.Schema, visit func(string, *spec.Schema)) {
+	if schema == nil {
+		return
+	}
+	visit(location, schema)
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property := schema.Properties[name]
+		walkSchema(location+"."+name, &property, visit)
+	}
+	if schema.Items != nil {
+		walkSchema(location+"[]", schema.Items.Schema, visit)
+	}
+	if schema.AdditionalProperties != nil {
+		walkSchema(location+"{}", schema.AdditionalProperties.Schema, visit)
+	}
+	for k, item := range schema.AllOf {
+		item := item
+		walkSchema(fmt.Sprintf("%s.allOf[%d]", location, k), &item, visit)
+	}
+}
+
+func sortedPaths(paths *spec.Paths) []string {
+	if paths == nil {
+		return nil
+	}
+	result := make([]string, 0, len(paths.Paths))
+	for name := range paths.Paths {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,54 @@
+package swagger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestWriter_Lint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(sw *Writer)
+		want   string
+	}{
+		{"valid", func(sw *Writer) {}, ""},
+		{"duplicate schemes", func(sw *Writer) {
+			sw.Schemes = []string{"http", "https", "https"}
+		}, `schemes: duplicate item "https"`},
+		{"duplicate operation produces", func(sw *Writer) {
+			sw.Swagger.Paths.Paths["/twirp/simple.SimpleService/Get"].Post.Produces = []string{"text/csv", "text/csv"}
+		}, `/twirp/simple.SimpleService/Get produces: duplicate item "text/csv"`},
+		{"undefined security", func(sw *Writer) {
+			sw.Swagger.Security = []map[string][]string{{"bearer": {}}}
+		}, `security: undefined security definition "bearer"`},
+		{"undefined operation security", func(sw *Writer) {
+			sw.SecurityDefinitions = spec.SecurityDefinitions{"bearer": spec.APIKeyAuth("Authorization", "header")}
+			sw.Swagger.Paths.Paths["/twirp/simple.SimpleService/List"].Post.Security = []map[string][]string{{"bearer": {}}, {"oauth2": {}}}
+		}, `/twirp/simple.SimpleService/List security: undefined security definition "oauth2"`},
+		{"missing version", func(sw *Writer) {
+			sw.Info.Version = ""
+		}, "info: title and version are required"},
+	}
+
+	for _, tc := range testCases {
+		writer := NewWriter("testdata/simple_service.proto", "api.example.com", "/twirp")
+		if err := writer.WalkFile(); err != nil {
+			t.Fatal(err)
+		}
+		tc.modify(writer)
+
+		err := writer.Lint()
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrLint) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package swagger
+
+import (
+	"github.com/go-openapi/spec"
+)
+
+// Values for WithFieldOrder.
+const (
+	FieldOrderDeclaration = "declaration"
+	FieldOrderProtoNumber = "proto_number"
+)
+
+// WriterOption configures optional Writer behaviour.
+type WriterOption func(*Writer)
+
+// WithVersion sets the API version in the document info.
+func WithVersion(version string) WriterOption {
+	return func(sw *Writer) {
+		sw.version = version
+	}
+}
+
+// WithTitle sets the info title, instead of the proto file name.
+func WithTitle(title string) WriterOption {
+	return func(sw *Writer) {
+		sw.title = title
+	}
+}
+
+// WithDescription sets the info description.
+func WithDescription(description string) WriterOption {
+	return func(sw *Writer) {
+		sw.description = description
+	}
+}
+
+// WithContact sets the contact info of the API. The email and url are
+// validated by WalkFile.
+func WithContact(name, email, url string) WriterOption {
+	return func(sw *Writer) {
+		sw.contact = contactInfo(name, email, url)
+	}
+}
+
+// WithNoAuth leaves out all the security definitions and requirements,
+// for fully public APIs.
+func WithNoAuth(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.noAuth = enabled
+	}
+}
+
+// WithAuthRequired keeps the security requirements, it's enabled by
+// default. When disabled, the security definitions are kept, so the
+// auth scheme is documented, but the document and the operations don't
+// require it.
+func WithAuthRequired(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.authRequired = enabled
+	}
+}
+
+// WithExtensions adds vendor extensions to the document root, like
+// `x-audience`, for portal specific needs.
+func WithExtensions(extensions map[string]interface{}) WriterOption {
+	return func(sw *Writer) {
+		sw.extensions = extensions
+	}
+}
+
+// WithRenames renames definitions in the output and the refs to them,
+// e.g. `com.example.Foo` to `Foo`, for shorter or clashing names.
+func WithRenames(renames map[string]string) WriterOption {
+	return func(sw *Writer) {
+		sw.renames = renames
+	}
+}
+
+// WithTermsOfService sets the terms of service url of the API.
+func WithTermsOfService(url string) WriterOption {
+	return func(sw *Writer) {
+		sw.termsOfService = url
+	}
+}
+
+// WithVersionInPath prefixes all paths with the major version, e.g.
+// `/v1/twirp/pkg.Service/Method` for version `1.2.3`.
+func WithVersionInPath(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.versionInPath = enabled
+	}
+}
+
+// WithAPIPrefix prefixes all paths with the path the API is mounted
+// under, e.g. `/v1/twirp/pkg.Service/Method` for `/v1`. The prefix
+// comes after the base path taken from the host.
+func WithAPIPrefix(prefix string) WriterOption {
+	return func(sw *Writer) {
+		sw.apiPrefix = prefix
+	}
+}
+
+// WithVisibilityOption sets the proto option name which is read from
+// fields and rpcs, and emitted as the `x-visibility` extension.
+func WithVisibilityOption(name string) WriterOption {
+	return func(sw *Writer) {
+		sw.visibilityOption = name
+	}
+}
+
+// WithSourceInfo enables the `x-proto-source` extension, holding the
+// proto file and line of each operation and definition.
+func WithSourceInfo(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.emitSourceInfo = enabled
+	}
+}
+
+// WithFieldsSuffix toggles the "Fields: ..." suffix which lists the
+// field order in each definition description. Enabled by default.
+func WithFieldsSuffix(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.fieldsSuffix = enabled
+	}
+}
+
+// WithWarnDeprecatedServices logs a warning for each service where all
+// the rpcs are deprecated.
+func WithWarnDeprecatedServices(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.warnDeprecatedServices = enabled
+	}
+}
+
+// WithStrict makes WalkFile fail with ErrUnresolvedRef for refs
+// without a definition, which are only logged otherwise.
+func WithStrict(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.strict = enabled
+	}
+}
+
+// WithLint makes WalkFile lint the generated document, see Lint.
+func WithLint(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.lint = enabled
+	}
+}
+
+// WithResponses declares reusable responses in the document, and
+// references them from every operation. See LoadResponses.
+func WithResponses(responses map[string]spec.Response) WriterOption {
+	return func(sw *Writer) {
+		sw.responses = responses
+	}
+}
+
+// WithDefinitionSeparator sets the separator between the package and
+// message name in definition keys. Defaults to `_`.
+func WithDefinitionSeparator(separator string) WriterOption {
+	return func(sw *Writer) {
+		if separator != "" {
+			sw.separator = separator
+		}
+	}
+}
+
+// WithFieldOrder emits the `x-order` extension on fields, either by
+// their declaration order or by their proto field number.
+func WithFieldOrder(order string) WriterOption {
+	return func(sw *Writer) {
+		sw.fieldOrder = order
+	}
+}
+
+// WithGatewayOptions reads the grpc-gateway openapiv2_operation and
+// openapiv2_schema options on rpcs and messages.
+func WithGatewayOptions(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.gatewayOptions = enabled
+	}
+}
+
+// WithSchemaRegistryURL sets the `x-schema-registry` extension, which
+// is emitted next to `x-schema-id` for messages with a `@schema-id`.
+func WithSchemaRegistryURL(url string) WriterOption {
+	return func(sw *Writer) {
+		sw.schemaRegistryURL = url
+	}
+}
+
+// WithGeneratorInfo adds the `x-generator` info extension, recording
+// the generator name and version, the api version and the input file.
+func WithGeneratorInfo(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.generatorInfo = enabled
+	}
+}
+
+// WithParallelImports parses up to n imported files concurrently
+// before the definitions are walked. The default of 1 parses each
+// import when it's walked.
+func WithParallelImports(n int) WriterOption {
+	return func(sw *Writer) {
+		if n > 0 {
+			sw.parallelImports = n
+		}
+	}
+}
+
+// WithFormatPatterns emits a pattern for string fields with a known
+// `format` tag: uuid, email and ipv4.
+func WithFormatPatterns(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.formatPatterns = enabled
+	}
+}
+
+// WithOverlay deep merges a partial swagger document, loaded with
+// LoadOverlay, onto the generated document. Arrays are appended to
+// unless arrays is OverlayArraysReplace.
+func WithOverlay(overlay map[string]interface{}, arrays string) WriterOption {
+	return func(sw *Writer) {
+		sw.overlay = overlay
+		sw.overlayArrays = arrays
+	}
+}
+
+// WithProtoPaths sets the directories searched for imported files, in
+// order, like protoc's -I flag.
+func WithProtoPaths(dirs []string) WriterOption {
+	return func(sw *Writer) {
+		sw.protoPaths = dirs
+	}
+}
+
+// WithServiceTags only includes services annotated with at least one
+// of the tags, e.g. `// @tag: public`. All services are included when
+// no tags are given.
+func WithServiceTags(tags []string) WriterOption {
+	return func(sw *Writer) {
+		sw.serviceTags = tags
+	}
+}
+
+// WithInlineEnums lists the enum values on fields, as a string schema
+// with `enum`, instead of referencing the enum definition.
+func WithInlineEnums(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.inlineEnums = enabled
+	}
+}
+
+// WithGeneratedBy adds the top level `x-generated-by` extension, with
+// the generator version, the input file and the generation time.
+func WithGeneratedBy(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.generatedBy = enabled
+	}
+}
+
+// WithGeneratorVersion sets the generator version recorded in the
+// `x-generator` and `x-generated-by` extensions, e.g. a version set
+// with `-ldflags "-X main.buildVersion=1.2.3"`. The module version from
+// the build info is used if it's empty.
+func WithGeneratorVersion(version string) WriterOption {
+	return func(sw *Writer) {
+		sw.binaryVersion = version
+	}
+}
+
+// WithAllOf emits messages with a single message field, like a common
+// header, as an allOf of that message and the other fields.
+func WithAllOf(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.useAllOf = enabled
+	}
+}
+
+// WithWrapRefs wraps the refs of fields with a comment in an allOf, so
+// the field title and description aren't dropped as $ref siblings.
+func WithWrapRefs(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.wrapRefs = enabled
+	}
+}
+
+// WithOnlyPackages only emits definitions from the listed packages and
+// the package of the main file. Refs to definitions which are left out
+// are an ErrFilteredRef error.
+func WithOnlyPackages(packages []string) WriterOption {
+	return func(sw *Writer) {
+		sw.onlyPackages = packages
+	}
+}
+
+// WithInt64AsString types 64bit integers as strings with an int64 or
+// uint64 format, which is the default. Without it they are integers.
+func WithInt64AsString(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.int64AsString = enabled
+	}
+}
+
+// WithParseCache shares parsed proto files between writers, e.g. for
+// the jobs of a manifest, which often import the same files.
+func WithParseCache(cache *ParseCache) WriterOption {
+	return func(sw *Writer) {
+		if cache != nil {
+			sw.cache = cache
+		}
+	}
+}
+
+// WithAutoTitles titles the definitions of messages without a comment
+// by their name, e.g. `UserProfile` as "User Profile". It's enabled by
+// default.
+func WithAutoTitles(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.autoTitles = enabled
+	}
+}
+
+// WithDefinitionOrder emits the `x-order` extension on definitions,
+// numbering the messages in the order they are declared across the
+// walked files, for renderers to sort the definitions by.
+func WithDefinitionOrder(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.definitionOrder = enabled
+	}
+}
+
+// WithPaginationTokenField sets the response field which marks an
+// operation as paginated with `x-ms-pageable`, `next_page_token` by
+// default. An empty name disables the extension.
+func WithPaginationTokenField(name string) WriterOption {
+	return func(sw *Writer) {
+		sw.paginationTokenField = name
+	}
+}
+
+// WithDefinitionsOnly leaves out the services, producing a document
+// with only the definitions, e.g. for client modeling. Files without
+// services are generated too.
+func WithDefinitionsOnly(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.definitionsOnly = enabled
+	}
+}
+
+// WithSkipWellKnown leaves out the definitions of google.protobuf
+// messages, which aren't mapped to a type alias. The refs to them are
+// kept, so the document has refs without definitions.
+func WithSkipWellKnown(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.skipWellKnown = enabled
+	}
+}
+
+// WithReport shares the diagnostics report between writers, so one
+// report covers all the inputs of a run.
+func WithReport(report *Report) WriterOption {
+	return func(sw *Writer) {
+		if report != nil {
+			sw.report = report
+		}
+	}
+}
+
+// WithStrictObjects sets `additionalProperties: false` on message
+// definitions, so clients reject unknown fields.
+func WithStrictObjects(enabled bool) WriterOption {
+	return func(sw *Writer) {
+		sw.strictObjects = enabled
+	}
+}
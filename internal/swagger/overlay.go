@@ -0,0 +1,113 @@
+package swagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-openapi/spec"
+)
+
+// Values for WithOverlay, controlling how overlay arrays are merged.
+const (
+	OverlayArraysAppend  = "append"
+	OverlayArraysReplace = "replace"
+)
+
+// LoadOverlay reads a partial swagger JSON document, which is merged
+// onto the generated document by WithOverlay.
+func LoadOverlay(filename string) (map[string]interface{}, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("can't decode overlay %s: %w", filename, err)
+	}
+	return result, nil
+}
+
+// applyOverlay deep merges the overlay onto the generated document.
+// Objects are merged key by key, and the overlay wins on scalars.
+// Arrays are appended to without duplicate scalars, or replaced with
+// OverlayArraysReplace.
+func (sw *Writer) applyOverlay() error {
+	body, err := json.Marshal(sw.Swagger)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return err
+	}
+
+	merged := mergeJSON(document, sw.overlay, sw.overlayArrays == OverlayArraysReplace)
+
+	body, err = json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	result := &spec.Swagger{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("can't apply overlay: %w", err)
+	}
+	sw.Swagger = result
+	return nil
+}
+
+func mergeJSON(base, overlay interface{}, replaceArrays bool) interface{} {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		baseMap, ok := base.(map[string]interface{})
+		if !ok {
+			return overlay
+		}
+		for key, value := range overlay {
+			baseMap[key] = mergeJSON(baseMap[key], value, replaceArrays)
+		}
+		return baseMap
+	case []interface{}:
+		baseSlice, ok := base.([]interface{})
+		if !ok || replaceArrays {
+			return overlay
+		}
+		return appendUnique(baseSlice, overlay)
+	default:
+		return overlay
+	}
+}
+
+// appendUnique appends the overlay items to base, skipping scalars
+// which are already in the array, e.g. a scheme listed in both, as
+// swagger arrays like schemes have unique items. Objects and arrays
+// are always appended.
+func appendUnique(base, overlay []interface{}) []interface{} {
+	seen := make(map[interface{}]bool)
+	for _, item := range base {
+		if isScalar(item) {
+			seen[item] = true
+		}
+	}
+	for _, item := range overlay {
+		if isScalar(item) {
+			if seen[item] {
+				continue
+			}
+			seen[item] = true
+		}
+		base = append(base, item)
+	}
+	return base
+}
+
+// isScalar reports if a decoded JSON value is a string, number, bool
+// or null, which are comparable as map keys.
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
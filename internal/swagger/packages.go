@@ -0,0 +1,41 @@
+package swagger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+var ErrFilteredRef = errors.New("reference to a definition from an excluded package")
+
+// includePackage reports if definitions from the current package are
+// emitted. The package of the main file is always included.
+func (sw *Writer) includePackage() bool {
+	if len(sw.onlyPackages) == 0 || sw.packageName == sw.mainPackage {
+		return true
+	}
+	for _, name := range sw.onlyPackages {
+		if name == sw.packageName {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFilteredRefs returns an error listing the refs to definitions
+// which were left out by WithOnlyPackages.
+func (sw *Writer) checkFilteredRefs() error {
+	problems := []string{}
+	sw.walkSchemas(func(location string, schema *spec.Schema) {
+		name := strings.TrimPrefix(schema.Ref.String(), "#/definitions/")
+		if sw.filtered[name] {
+			problems = append(problems, fmt.Sprintf("%s: %s", location, name))
+		}
+	})
+	if len(problems) > 0 {
+		return fmt.Errorf("%w, add the packages to only_packages: %s", ErrFilteredRef, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package swagger
+
+import (
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// pageSizeField is the request field emitted as `x-page-size-field`.
+const pageSizeField = "page_size"
+
+// markPageable adds `x-ms-pageable` to the operations which respond
+// with the pagination token field, and `x-page-size-field` to the
+// operations with a page size request field. The messages may be
+// declared after the services, so it's done once the file was walked.
+func (sw *Writer) markPageable() {
+	if sw.paginationTokenField == "" {
+		return
+	}
+	for _, pathName := range sortedPaths(sw.Swagger.Paths) {
+		operation := sw.Swagger.Paths.Paths[pathName].Post
+		if operation == nil {
+			continue
+		}
+		if response, ok := operation.Responses.StatusCodeResponses[200]; ok && sw.hasProperty(response.Schema, sw.paginationTokenField) {
+			operation.AddExtension("x-ms-pageable", map[string]string{
+				"nextLinkName": sw.paginationTokenField,
+			})
+		}
+		for _, param := range operation.Parameters {
+			if param.In == "body" && sw.hasProperty(param.Schema, pageSizeField) {
+				operation.AddExtension("x-page-size-field", pageSizeField)
+			}
+		}
+	}
+}
+
+// hasProperty reports if a schema, or the definition it refers to,
+// has the named property. The parts of an allOf are checked too.
+func (sw *Writer) hasProperty(schema *spec.Schema, name string) bool {
+	if schema == nil {
+		return false
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		definition, ok := sw.Swagger.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !ok {
+			return false
+		}
+		schema = &definition
+	}
+	if _, ok := schema.Properties[name]; ok {
+		return true
+	}
+	for k := range schema.AllOf {
+		if sw.hasProperty(&schema.AllOf[k], name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package swagger
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/proto"
+)
+
+// RateLimit is emitted as the `x-ratelimit` operation extension.
+type RateLimit struct {
+	Limit int    `json:"limit"`
+	Unit  string `json:"unit"`
+	Burst int    `json:"burst,omitempty"`
+}
+
+var rateLimitUnits = map[string]bool{
+	"second": true,
+	"minute": true,
+	"hour":   true,
+	"day":    true,
+}
+
+// parseRateLimit reads a `@ratelimit 100/minute` annotation from a
+// comment. A burst can be given as `@ratelimit burst:200 rate:100/minute`.
+// Malformed annotations are ignored with a warning.
+func parseRateLimit(comment *proto.Comment, logger *slog.Logger) *RateLimit {
+	values := annotations(comment, "ratelimit")
+	if len(values) == 0 {
+		return nil
+	}
+	value := values[0]
+
+	result := &RateLimit{}
+	for _, field := range strings.Fields(value) {
+		key, val := "rate", field
+		if idx := strings.Index(field, ":"); idx >= 0 {
+			key, val = field[:idx], field[idx+1:]
+		}
+
+		switch key {
+		case "rate":
+			parts := strings.SplitN(val, "/", 2)
+			limit, err := strconv.Atoi(parts[0])
+			if err != nil || limit <= 0 || len(parts) != 2 || !rateLimitUnits[parts[1]] {
+				logger.Warn("ignoring malformed ratelimit annotation", "annotation", value)
+				return nil
+			}
+			result.Limit, result.Unit = limit, parts[1]
+		case "burst":
+			burst, err := strconv.Atoi(val)
+			if err != nil || burst <= 0 {
+				logger.Warn("ignoring malformed ratelimit annotation", "annotation", value)
+				return nil
+			}
+			result.Burst = burst
+		default:
+			logger.Warn("ignoring malformed ratelimit annotation", "annotation", value)
+			return nil
+		}
+	}
+	if result.Limit == 0 {
+		logger.Warn("ignoring ratelimit annotation without a rate", "annotation", value)
+		return nil
+	}
+	return result
+}
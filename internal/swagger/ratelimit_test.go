@@ -0,0 +1,33 @@
+package swagger
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/emicklei/proto"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	testCases := []struct {
+		line string
+		want *RateLimit
+	}{
+		{"@ratelimit 100/minute", &RateLimit{Limit: 100, Unit: "minute"}},
+		{"@ratelimit: 5/second", &RateLimit{Limit: 5, Unit: "second"}},
+		{"@ratelimit burst:200 rate:100/minute", &RateLimit{Limit: 100, Unit: "minute", Burst: 200}},
+		{"@ratelimit rate:1000/day", &RateLimit{Limit: 1000, Unit: "day"}},
+		{"@ratelimit 100/fortnight", nil},
+		{"@ratelimit many/minute", nil},
+		{"@ratelimit burst:200", nil},
+		{"@ratelimit", nil},
+		{"no annotation", nil},
+	}
+
+	for _, tc := range testCases {
+		comment := &proto.Comment{Lines: []string{"Get a thing", tc.line}}
+		if got := parseRateLimit(comment, slog.Default()); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %+v, want %+v", tc.line, got, tc.want)
+		}
+	}
+}
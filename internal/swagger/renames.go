@@ -0,0 +1,131 @@
+package swagger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+var ErrRenameConflict = errors.New("conflicting definition renames")
+
+// applyRenames renames the definitions selected with WithRenames, and
+// the payloads of the streaming rpcs. The names may be given as the
+// definition key, or as the qualified proto name, e.g. `com.example.Foo`.
+// Renames of unknown definitions are skipped with a warning. Renames to
+// an existing definition, or of several definitions to the same name,
+// are an ErrRenameConflict error.
+func (sw *Writer) applyRenames() error {
+	froms := make([]string, 0, len(sw.renames))
+	for from := range sw.renames {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	renames := make(map[string]string)
+	sources := make(map[string]string)
+	for _, from := range froms {
+		to := sw.renames[from]
+		name := from
+		if _, ok := sw.Swagger.Definitions[name]; !ok {
+			name = sw.definitionName(from)
+		}
+		if _, ok := sw.Swagger.Definitions[name]; !ok {
+			sw.logger().Warn("skipping rename of unknown definition", "from", from, "to", to)
+			continue
+		}
+		if _, ok := sw.Swagger.Definitions[to]; ok {
+			return fmt.Errorf("%w: %s to existing definition %s", ErrRenameConflict, from, to)
+		}
+		if other, ok := sources[to]; ok {
+			return fmt.Errorf("%w: %s and %s both renamed to %s", ErrRenameConflict, other, from, to)
+		}
+		sources[to] = from
+		renames[name] = to
+	}
+	postProcessRenames(sw.Swagger, renames)
+
+	// the streaming rpcs keep their payload names for GetAsyncAPI
+	for k, stream := range sw.streams {
+		if to, ok := renames[stream.request]; ok {
+			sw.streams[k].request = to
+		}
+		if to, ok := renames[stream.response]; ok {
+			sw.streams[k].response = to
+		}
+	}
+	return nil
+}
+
+// postProcessRenames renames definitions, and rewrites the refs to
+// them in the operations, the shared responses and the definitions,
+// including the refs in allOf, items and additionalProperties.
+func postProcessRenames(swagger *spec.Swagger, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+
+	definitions := make(spec.Definitions, len(swagger.Definitions))
+	for name, schema := range swagger.Definitions {
+		renameRefs(&schema, renames)
+		if to, ok := renames[name]; ok {
+			name = to
+		}
+		definitions[name] = schema
+	}
+	swagger.Definitions = definitions
+
+	for code, response := range swagger.Responses {
+		renameRefs(response.Schema, renames)
+		swagger.Responses[code] = response
+	}
+
+	if swagger.Paths == nil {
+		return
+	}
+	for _, item := range swagger.Paths.Paths {
+		if item.Post == nil {
+			continue
+		}
+		for k := range item.Post.Parameters {
+			renameRefs(item.Post.Parameters[k].Schema, renames)
+		}
+		if item.Post.Responses == nil {
+			continue
+		}
+		if item.Post.Responses.Default != nil {
+			renameRefs(item.Post.Responses.Default.Schema, renames)
+		}
+		for _, response := range item.Post.Responses.StatusCodeResponses {
+			renameRefs(response.Schema, renames)
+		}
+	}
+}
+
+// renameRefs rewrites the refs in schema and the nested schemas. The
+// nested schemas are stored by value, so they're written back.
+func renameRefs(schema *spec.Schema, renames map[string]string) {
+	if schema == nil {
+		return
+	}
+	name := strings.TrimPrefix(schema.Ref.String(), "#/definitions/")
+	if to, ok := renames[name]; ok {
+		schema.Ref = spec.MustCreateRef("#/definitions/" + to)
+	}
+
+	for key, property := range schema.Properties {
+		renameRefs(&property, renames)
+		schema.Properties[key] = property
+	}
+	if schema.Items != nil {
+		renameRefs(schema.Items.Schema, renames)
+	}
+	if schema.AdditionalProperties != nil {
+		renameRefs(schema.AdditionalProperties.Schema, renames)
+	}
+	for k := range schema.AllOf {
+		renameRefs(&schema.AllOf[k], renames)
+	}
+}
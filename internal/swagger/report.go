@@ -0,0 +1,174 @@
+package swagger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+)
+
+var ErrUnresolvedRef = errors.New("ref without a definition")
+
+// Report holds the diagnostics collected while generating, so CI can
+// check for soft failures which are otherwise only logged. Each writer
+// has its own report by default, and writers for several inputs can
+// share one WithReport. It's safe for concurrent use.
+type Report struct {
+	mu sync.Mutex
+
+	SkippedFiles   []SkippedFile   `json:"skipped_files"`
+	UnresolvedRefs []UnresolvedRef `json:"unresolved_refs"`
+	Warnings       []Warning       `json:"warnings"`
+}
+
+// SkippedFile is an import which couldn't be loaded.
+type SkippedFile struct {
+	File   string `json:"file"`
+	Import string `json:"import"`
+	Error  string `json:"error"`
+}
+
+// UnresolvedRef is a ref without a definition in the generated document.
+type UnresolvedRef struct {
+	File     string `json:"file"`
+	Location string `json:"location"`
+	Ref      string `json:"ref"`
+}
+
+// Warning is a logged warning with its log fields.
+type Warning struct {
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// NewReport creates an empty report.
+func NewReport() *Report {
+	return &Report{
+		SkippedFiles:   []SkippedFile{},
+		UnresolvedRefs: []UnresolvedRef{},
+		Warnings:       []Warning{},
+	}
+}
+
+// Get returns the report as indented JSON.
+func (r *Report) Get() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, _ := json.MarshalIndent(r, "", "  ")
+	return b
+}
+
+// Save writes the report as JSON to filename.
+func (r *Report) Save(filename string) error {
+	return ioutil.WriteFile(filename, r.Get(), os.ModePerm^0111)
+}
+
+func (r *Report) addSkippedFile(file SkippedFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.SkippedFiles = append(r.SkippedFiles, file)
+}
+
+func (r *Report) addUnresolvedRef(ref UnresolvedRef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.UnresolvedRefs = append(r.UnresolvedRefs, ref)
+}
+
+func (r *Report) addWarning(warning Warning) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Warnings = append(r.Warnings, warning)
+}
+
+// Report returns the diagnostics collected by the writer.
+func (sw *Writer) Report() *Report {
+	return sw.report
+}
+
+// reportHandler records the warnings with their attributes in the
+// report, and passes all records on to the next handler.
+type reportHandler struct {
+	next   slog.Handler
+	report *Report
+	attrs  []slog.Attr
+}
+
+func (h *reportHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn || h.next.Enabled(ctx, level)
+}
+
+func (h *reportHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelWarn {
+		fields := make(map[string]interface{})
+		addField := func(attr slog.Attr) bool {
+			value := attr.Value.Resolve().Any()
+			if err, ok := value.(error); ok {
+				value = err.Error()
+			}
+			fields[attr.Key] = value
+			return true
+		}
+		for _, attr := range h.attrs {
+			addField(attr)
+		}
+		record.Attrs(addField)
+		h.report.addWarning(Warning{
+			Message: record.Message,
+			Fields:  fields,
+		})
+	}
+	if !h.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *reportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &reportHandler{
+		next:   h.next.WithAttrs(attrs),
+		report: h.report,
+		attrs:  append(append([]slog.Attr{}, h.attrs...), attrs...),
+	}
+}
+
+func (h *reportHandler) WithGroup(name string) slog.Handler {
+	return &reportHandler{
+		next:   h.next.WithGroup(name),
+		report: h.report,
+		attrs:  h.attrs,
+	}
+}
+
+// checkUnresolvedRefs records the refs without a definition, like refs
+// to messages from imports which were skipped or misnamed, and logs them
+// as warnings. In strict mode, an error listing the refs is returned.
+func (sw *Writer) checkUnresolvedRefs() error {
+	problems := []string{}
+	sw.walkSchemas(func(location string, schema *spec.Schema) {
+		ref, ok := sw.unresolvedRef(schema)
+		if !ok {
+			return
+		}
+		// logged without sw.logger, as the refs have their own list
+		// in the report
+		slog.Warn("unresolved ref", "file", sw.filename, "location", location, "ref", ref)
+		sw.report.addUnresolvedRef(UnresolvedRef{
+			File:     sw.filename,
+			Location: location,
+			Ref:      ref,
+		})
+		problems = append(problems, fmt.Sprintf("%s: %s", location, ref))
+	})
+	if sw.strict && len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnresolvedRef, strings.Join(problems, "; "))
+	}
+	return nil
+}
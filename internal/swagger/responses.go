@@ -0,0 +1,65 @@
+package swagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/proto"
+	"github.com/go-openapi/spec"
+)
+
+// LoadResponses reads a JSON fragment of reusable responses, keyed by
+// their status code, e.g. `{"401": {"description": "Unauthorized"}}`.
+func LoadResponses(filename string) (map[string]spec.Response, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]spec.Response)
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("can't decode responses %s: %w", filename, err)
+	}
+	for name := range result {
+		if _, err := strconv.Atoi(name); err != nil {
+			return nil, fmt.Errorf("response %q in %s: key must be a status code", name, filename)
+		}
+	}
+	return result, nil
+}
+
+// parseResponseAnnotations reads `@response 200: Description` lines
+// from a comment, returning the descriptions by status code. Malformed
+// annotations are ignored with a warning.
+func parseResponseAnnotations(comment *proto.Comment, logger *slog.Logger) map[int]string {
+	result := make(map[int]string)
+	for _, value := range annotations(comment, "response") {
+		parts := strings.SplitN(value, ":", 2)
+		code, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || len(parts) != 2 {
+			logger.Warn("ignoring malformed response annotation", "annotation", value)
+			continue
+		}
+		result[code] = strings.TrimSpace(parts[1])
+	}
+	return result
+}
+
+// addGlobalResponses references the global responses from an operation.
+func (sw *Writer) addGlobalResponses(operation *spec.Operation) {
+	for name := range sw.responses {
+		code, _ := strconv.Atoi(name)
+		if _, ok := operation.Responses.StatusCodeResponses[code]; ok {
+			continue
+		}
+		operation.Responses.StatusCodeResponses[code] = spec.Response{
+			Refable: spec.Refable{
+				Ref: spec.MustCreateRef("#/responses/" + name),
+			},
+		}
+	}
+}
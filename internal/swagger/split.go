@@ -0,0 +1,132 @@
+package swagger
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Services returns the names of the services walked, in declaration order.
+func (sw *Writer) Services() []string {
+	return sw.services
+}
+
+// GetService returns a document containing only the paths for the
+// named service, with the definitions reachable from them, and the
+// tags and global responses its operations use.
+func (sw *Writer) GetService(name string) []byte {
+	doc := *sw.Swagger
+	doc.Paths = &spec.Paths{
+		Paths: make(map[string]spec.PathItem),
+	}
+	doc.Definitions = make(spec.Definitions)
+
+	pending := []string{}
+	tags := make(map[string]bool)
+	responses := make(map[string]bool)
+	for _, pathName := range sw.servicePaths[name] {
+		item := sw.Swagger.Paths.Paths[pathName]
+		doc.Paths.Paths[pathName] = item
+		pending = append(pending, operationRefs(item.Post)...)
+		if item.Post == nil {
+			continue
+		}
+		for _, tag := range item.Post.Tags {
+			tags[tag] = true
+		}
+		if item.Post.Responses != nil {
+			for _, response := range item.Post.Responses.StatusCodeResponses {
+				if ref := response.Ref.String(); strings.HasPrefix(ref, "#/responses/") {
+					responses[strings.TrimPrefix(ref, "#/responses/")] = true
+				}
+			}
+		}
+	}
+
+	doc.Tags = nil
+	for _, tag := range sw.Swagger.Tags {
+		if tags[tag.Name] {
+			doc.Tags = append(doc.Tags, tag)
+		}
+	}
+	doc.Responses = nil
+	for responseName, response := range sw.Swagger.Responses {
+		if !responses[responseName] {
+			continue
+		}
+		if doc.Responses == nil {
+			doc.Responses = make(map[string]spec.Response)
+		}
+		doc.Responses[responseName] = response
+	}
+
+	// walk the definition graph until no new refs are found
+	for len(pending) > 0 {
+		definitionName := pending[0]
+		pending = pending[1:]
+		if _, ok := doc.Definitions[definitionName]; ok {
+			continue
+		}
+		schema, ok := sw.Swagger.Definitions[definitionName]
+		if !ok {
+			continue
+		}
+		doc.Definitions[definitionName] = schema
+		pending = append(pending, schemaRefs(&schema)...)
+	}
+
+	b, _ := json.MarshalIndent(doc, "", "  ")
+	return b
+}
+
+// operationRefs returns the definition names referenced by an operation.
+func operationRefs(operation *spec.Operation) []string {
+	if operation == nil {
+		return nil
+	}
+	result := []string{}
+	for _, param := range operation.Parameters {
+		result = append(result, schemaRefs(param.Schema)...)
+	}
+	if operation.Responses != nil {
+		for _, response := range operation.Responses.StatusCodeResponses {
+			result = append(result, schemaRefs(response.Schema)...)
+		}
+		if response := operation.Responses.Default; response != nil {
+			result = append(result, schemaRefs(response.Schema)...)
+		}
+	}
+	return result
+}
+
+// schemaRefs returns the definition names referenced by a schema,
+// including nested property, item and composition schemas.
+func schemaRefs(schema *spec.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	result := []string{}
+	if ref := schema.Ref.String(); strings.HasPrefix(ref, "#/definitions/") {
+		result = append(result, strings.TrimPrefix(ref, "#/definitions/"))
+	}
+	for _, property := range schema.Properties {
+		property := property
+		result = append(result, schemaRefs(&property)...)
+	}
+	if schema.Items != nil {
+		result = append(result, schemaRefs(schema.Items.Schema)...)
+		for _, item := range schema.Items.Schemas {
+			item := item
+			result = append(result, schemaRefs(&item)...)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		result = append(result, schemaRefs(schema.AdditionalProperties.Schema)...)
+	}
+	for _, item := range schema.AllOf {
+		item := item
+		result = append(result, schemaRefs(&item)...)
+	}
+	return result
+}
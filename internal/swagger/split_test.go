@@ -0,0 +1,47 @@
+package swagger
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestWriter_GetService(t *testing.T) {
+	responses := map[string]spec.Response{
+		"200": *spec.NewResponse().WithDescription("Overridden by every rpc"),
+		"401": *spec.NewResponse().WithDescription("Unauthorized"),
+	}
+	writer := NewWriter("testdata/service_tags.proto", "api.example.com", "/twirp", WithResponses(responses))
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range writer.Services() {
+		doc := &spec.Swagger{}
+		if err := json.Unmarshal(writer.GetService(name), doc); err != nil {
+			t.Fatal(err)
+		}
+
+		paths := sortedPaths(doc.Paths)
+		if len(paths) != 1 || paths[0] != writer.servicePaths[name][0] {
+			t.Errorf("%s: got paths %v, want %v", name, paths, writer.servicePaths[name])
+		}
+		if len(doc.Tags) != 1 || doc.Tags[0].Name != name {
+			t.Errorf("%s: got tags %v, want only %s", name, doc.Tags, name)
+		}
+		if _, ok := doc.Responses["401"]; !ok || len(doc.Responses) != 1 {
+			t.Errorf("%s: got responses %v, want only 401", name, doc.Responses)
+		}
+
+		definitions := []string{}
+		for definitionName := range doc.Definitions {
+			definitions = append(definitions, definitionName)
+		}
+		sort.Strings(definitions)
+		if len(definitions) != 2 || definitions[0] != "audiences_Request" || definitions[1] != "audiences_Response" {
+			t.Errorf("%s: got definitions %v, want the request and response", name, definitions)
+		}
+	}
+}
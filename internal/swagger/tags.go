@@ -0,0 +1,326 @@
+package swagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emicklei/proto"
+	"github.com/go-openapi/spec"
+)
+
+// knownTags lists the comment tags which may follow a `;` in a
+// comment line, e.g. `// Status code; enum:0=OK,1=ERROR`. Segments
+// which don't start with a known tag are kept as comment text.
+var knownTags = map[string]bool{
+	"enum":       true,
+	"deprecated": true,
+	"tag":        true,
+	"required":   true,
+	"hidden":     true,
+	"format":     true,
+	"pattern":    true,
+	"example":    true,
+	"range":      true,
+
+	// produces sets the content type of an rpc returning e.g. CSV,
+	// with the response typed as binary.
+	"produces": true,
+
+	// unauthenticated marks a public service, overriding the
+	// document security requirements with `security: []`.
+	"unauthenticated": true,
+}
+
+// knownAnnotations lists the comment annotations, which are lines like
+// `// @schema-id: 12345`. The lines are not part of the comment text.
+var knownAnnotations = map[string]bool{
+	"schema-id": true,
+	"response":  true,
+	"ratelimit": true,
+	"tag":       true,
+
+	// deprecation timeline of an rpc, as `2006-01-02` dates
+	"deprecated_since": true,
+	"sunset":           true,
+}
+
+// hidden reports if the field is tagged with `hidden`, which leaves it
+// out of the schema entirely.
+func hidden(field *proto.Field) bool {
+	_, ok := commentTags(fieldComment(field))["hidden"]
+	return ok
+}
+
+// fieldComment returns the comment above a field, or the trailing
+// comment on the same line, like `string name = 1; // Full name`.
+func fieldComment(field *proto.Field) *proto.Comment {
+	if field.Comment != nil {
+		return field.Comment
+	}
+	return field.InlineComment
+}
+
+// parseAnnotation returns the name and value of an annotation line.
+func parseAnnotation(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "@") {
+		return "", "", false
+	}
+	name := line[1:]
+	value := ""
+	if idx := strings.IndexAny(name, ": \t"); idx >= 0 {
+		name, value = name[:idx], name[idx+1:]
+	}
+	if !knownAnnotations[name] {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), ":")), true
+}
+
+// annotations returns the values of the named annotation, in order.
+func annotations(comment *proto.Comment, name string) []string {
+	result := []string{}
+	if comment == nil {
+		return result
+	}
+	for _, line := range comment.Lines {
+		if key, value, ok := parseAnnotation(line); ok && key == name {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// dateAnnotation returns the last valid `2006-01-02` date of the named
+// annotation. Invalid dates are ignored with a warning.
+func dateAnnotation(comment *proto.Comment, name string, logger *slog.Logger) (string, bool) {
+	result := ""
+	for _, value := range annotations(comment, name) {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			logger.Warn("ignoring invalid date, want YYYY-MM-DD", "annotation", name, "date", value)
+			continue
+		}
+		result = value
+	}
+	return result, result != ""
+}
+
+// parseServiceTags returns the audience tags from `@tag: public,beta`
+// annotations on a service comment.
+func parseServiceTags(comment *proto.Comment) []string {
+	result := []string{}
+	for _, value := range annotations(comment, "tag") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result = append(result, name)
+			}
+		}
+	}
+	return result
+}
+
+// splitTags separates the comment text from the known tags in a line.
+// Leading whitespace is kept, so indentation can be preserved.
+// Annotation lines have no comment text.
+func splitTags(line string) (string, map[string]string) {
+	tags := make(map[string]string)
+	if _, _, ok := parseAnnotation(line); ok {
+		return "", tags
+	}
+	segments := strings.Split(line, ";")
+	text := []string{segments[0]}
+	for _, segment := range segments[1:] {
+		key, value := segment, ""
+		if idx := strings.Index(segment, ":"); idx >= 0 {
+			key, value = segment[:idx], segment[idx+1:]
+		}
+		key = strings.TrimSpace(key)
+		if !knownTags[key] {
+			text = append(text, segment)
+			continue
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return strings.TrimRight(strings.Join(text, ";"), " \t"), tags
+}
+
+// commentTags collects the known tags from all comment lines.
+func commentTags(comment *proto.Comment) map[string]string {
+	result := make(map[string]string)
+	if comment == nil {
+		return result
+	}
+	for _, line := range comment.Lines {
+		_, tags := splitTags(line)
+		for k, v := range tags {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// formatPatterns maps string formats to the pattern emitted for them
+// when format patterns are enabled.
+var formatPatterns = map[string]string{
+	"uuid":  `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+	"email": `^[^@\s]+@[^@\s]+\.[^@\s]+$`,
+	"ipv4":  `^((25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])$`,
+}
+
+// formatTag applies the `format:uuid` and `pattern:^[a-z]+$` tags to a
+// string schema. A pattern tag takes precedence over the pattern of
+// the format.
+func (sw *Writer) formatTag(schema *spec.Schema, tags map[string]string) {
+	if format, ok := tags["format"]; ok && schema.Format == "" {
+		schema.Format = format
+		if sw.formatPatterns {
+			schema.Pattern = formatPatterns[format]
+		}
+	}
+	if pattern, ok := tags["pattern"]; ok {
+		schema.Pattern = pattern
+	}
+}
+
+// rangeBound is one side of a `range:` tag, nil values are unbounded.
+type rangeBound struct {
+	value     *float64
+	exclusive bool
+}
+
+// parseRange parses a `0..150` range. Bounds are inclusive, and can be
+// made exclusive with parentheses like `(0..150]`. Either bound may be
+// left out, e.g. `1..`.
+func parseRange(value string) (rangeBound, rangeBound, error) {
+	var lower, upper rangeBound
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, "("):
+		lower.exclusive = true
+		value = value[1:]
+	case strings.HasPrefix(value, "["):
+		value = value[1:]
+	}
+	switch {
+	case strings.HasSuffix(value, ")"):
+		upper.exclusive = true
+		value = value[:len(value)-1]
+	case strings.HasSuffix(value, "]"):
+		value = value[:len(value)-1]
+	}
+
+	parts := strings.Split(value, "..")
+	if len(parts) != 2 {
+		return lower, upper, fmt.Errorf("want a range like 0..150")
+	}
+	for k, bound := range []*rangeBound{&lower, &upper} {
+		part := strings.TrimSpace(parts[k])
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return lower, upper, err
+		}
+		bound.value = &v
+	}
+	if lower.value == nil && upper.value == nil {
+		return lower, upper, fmt.Errorf("want at least one bound")
+	}
+	if lower.value != nil && upper.value != nil && *lower.value > *upper.value {
+		return lower, upper, fmt.Errorf("minimum is greater than maximum")
+	}
+	return lower, upper, nil
+}
+
+// rangeTag applies a `range:0..150` tag to a numeric schema. The tag
+// is ignored with a warning for other fields, or if it's malformed.
+func rangeTag(schema *spec.Schema, logger *slog.Logger, value string) {
+	if !schema.Type.Contains("integer") && !schema.Type.Contains("number") {
+		logger.Warn("ignoring range tag on a non-numeric field, 64bit integers need -int64_as_string=false", "tag", value)
+		return
+	}
+	lower, upper, err := parseRange(value)
+	if err != nil {
+		logger.Warn("ignoring malformed range tag", "tag", value, "error", err)
+		return
+	}
+	schema.Minimum, schema.ExclusiveMinimum = lower.value, lower.exclusive && lower.value != nil
+	schema.Maximum, schema.ExclusiveMaximum = upper.value, upper.exclusive && upper.value != nil
+}
+
+// exampleTag returns the value of an `example:` tag for a field. The
+// example is for the whole field, so repeated fields take a JSON array
+// and a single value is wrapped into one. Scalars are parsed by the
+// field type, and messages and maps take a JSON value.
+func exampleTag(value, fieldType string, repeated bool) interface{} {
+	if repeated {
+		var list []interface{}
+		if err := json.Unmarshal([]byte(value), &list); err == nil {
+			return list
+		}
+		return []interface{}{exampleValue(value, fieldType)}
+	}
+	return exampleValue(value, fieldType)
+}
+
+func exampleValue(value, fieldType string) interface{} {
+	switch fieldType {
+	case "integer":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	case "string":
+		if v, err := strconv.Unquote(value); err == nil {
+			return v
+		}
+		return value
+	}
+
+	var result interface{}
+	if err := json.Unmarshal([]byte(value), &result); err == nil {
+		return result
+	}
+	return value
+}
+
+// enumTag applies an `enum:0=OK,1=ERROR` tag to an integer schema.
+// Malformed tags are ignored with a warning.
+func (sw *Writer) enumTag(schema *spec.Schema, logger *slog.Logger, values string, asString bool) {
+	var (
+		enum         []interface{}
+		descriptions []string
+	)
+	for _, pair := range strings.Split(values, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			logger.Warn("ignoring malformed enum tag", "tag", values)
+			return
+		}
+		value, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+		if err != nil {
+			logger.Warn("ignoring malformed enum tag", "tag", values, "error", err)
+			return
+		}
+		if asString {
+			enum = append(enum, strconv.FormatInt(value, 10))
+		} else {
+			enum = append(enum, value)
+		}
+		descriptions = append(descriptions, strings.TrimSpace(parts[1]))
+	}
+	schema.Enum = enum
+	schema.AddExtension("x-enum-descriptions", descriptions)
+}
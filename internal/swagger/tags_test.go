@@ -0,0 +1,42 @@
+package swagger
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	float := func(v float64) *float64 {
+		return &v
+	}
+	testCases := []struct {
+		in           string
+		lower, upper rangeBound
+		valid        bool
+	}{
+		{"0..150", rangeBound{value: float(0)}, rangeBound{value: float(150)}, true},
+		{"[0..150]", rangeBound{value: float(0)}, rangeBound{value: float(150)}, true},
+		{"(0..1]", rangeBound{value: float(0), exclusive: true}, rangeBound{value: float(1)}, true},
+		{"-1.5..1.5)", rangeBound{value: float(-1.5)}, rangeBound{value: float(1.5), exclusive: true}, true},
+		{"1..", rangeBound{value: float(1)}, rangeBound{}, true},
+		{"..100", rangeBound{}, rangeBound{value: float(100)}, true},
+		{"..", rangeBound{}, rangeBound{}, false},
+		{"10..1", rangeBound{}, rangeBound{}, false},
+		{"0-150", rangeBound{}, rangeBound{}, false},
+		{"a..b", rangeBound{}, rangeBound{}, false},
+	}
+
+	equal := func(a, b rangeBound) bool {
+		if a.exclusive != b.exclusive || (a.value == nil) != (b.value == nil) {
+			return false
+		}
+		return a.value == nil || *a.value == *b.value
+	}
+	for _, tc := range testCases {
+		lower, upper, err := parseRange(tc.in)
+		if (err == nil) != tc.valid {
+			t.Errorf("%q: got error %v, want valid %t", tc.in, err, tc.valid)
+			continue
+		}
+		if tc.valid && (!equal(lower, tc.lower) || !equal(upper, tc.upper)) {
+			t.Errorf("%q: got (%+v, %+v), want (%+v, %+v)", tc.in, lower, upper, tc.lower, tc.upper)
+		}
+	}
+}
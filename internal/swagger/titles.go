@@ -0,0 +1,40 @@
+package swagger
+
+import (
+	"strings"
+	"unicode"
+)
+
+// humanName splits a message name into words, e.g. `UserProfile`
+// becomes "User Profile". Acronyms are kept together, so `HTTPRequest`
+// becomes "HTTP Request", and underscores separate words too.
+func humanName(name string) string {
+	runes := []rune(name)
+	words := []string{}
+	start := 0
+	for k := 1; k < len(runes); k++ {
+		prev, curr := runes[k-1], runes[k]
+		boundary := false
+		switch {
+		case curr == '_':
+			boundary = true
+		case unicode.IsUpper(curr) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			boundary = true
+		case unicode.IsUpper(prev) && unicode.IsUpper(curr) && k+1 < len(runes) && unicode.IsLower(runes[k+1]):
+			boundary = true
+		}
+		if boundary {
+			words = append(words, string(runes[start:k]))
+			start = k
+		}
+	}
+	words = append(words, string(runes[start:]))
+
+	result := []string{}
+	for _, word := range words {
+		if word = strings.Trim(word, "_"); word != "" {
+			result = append(result, word)
+		}
+	}
+	return strings.Join(result, " ")
+}
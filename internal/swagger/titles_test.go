@@ -0,0 +1,23 @@
+package swagger
+
+import "testing"
+
+func TestHumanName(t *testing.T) {
+	testCases := []struct {
+		in, want string
+	}{
+		{"User", "User"},
+		{"UserProfile", "User Profile"},
+		{"HTTPRequest", "HTTP Request"},
+		{"GetUserByID", "Get User By ID"},
+		{"V2Request", "V2 Request"},
+		{"user_profile", "user profile"},
+		{"Page_Info", "Page Info"},
+	}
+
+	for _, tc := range testCases {
+		if got := humanName(tc.in); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -1,41 +1,191 @@
 package swagger
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"os"
 	"path"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"text/scanner"
 
-	"github.com/apex/log"
 	"github.com/emicklei/proto"
 	"github.com/go-openapi/spec"
 )
 
 var ErrNoServiceDefinition = errors.New("no service definition found")
 
+var ErrInvalidVersion = errors.New("version without a major version number")
+
 type Writer struct {
 	*spec.Swagger
 
 	filename    string
 	hostname    string
+	scheme      string
+	basePath    string
 	pathPrefix  string
 	packageName string
+	separator   string
+	currentFile string
+	version     string
+	title       string
+	description string
+
+	contact        *spec.ContactInfo
+	termsOfService string
+	noAuth         bool
+	authRequired   bool
+
+	// optionTitle and optionDescription are read from the
+	// twirp_swagger.proto file options of the main file.
+	optionTitle       string
+	optionDescription string
+
+	// syntax is the syntax statement of the main file.
+	syntax string
+
+	// extensions are added to the document root as is.
+	extensions map[string]interface{}
+
+	// renames maps definition or proto names to new definition names.
+	renames map[string]string
+
+	// apiPrefix is prepended to the paths, before the twirp prefix.
+	apiPrefix string
+
+	// services keeps the declared service names in order, and
+	// servicePaths maps each service to the paths of its rpcs.
+	services     []string
+	servicePaths map[string][]string
+	streams      []streamingRPC
+
+	visibilityOption string
+	emitSourceInfo   bool
+	fieldsSuffix     bool
+	autoTitles       bool
+
+	warnDeprecatedServices bool
+	lint                   bool
+	strict                 bool
+
+	responses  map[string]spec.Response
+	fieldOrder string
+
+	definitionOrder bool
+	definitionCount int
+
+	gatewayOptions    bool
+	schemaRegistryURL string
+	versionInPath     bool
+	generatorInfo     bool
+	generatedBy       bool
+	binaryVersion     string
+
+	formatPatterns bool
+	int64AsString  bool
+	strictObjects  bool
+	protoPaths     []string
+	serviceTags    []string
+
+	paginationTokenField string
+	definitionsOnly      bool
+	parallelImports      int
+
+	// enums maps enum definition names to their value names, which
+	// are inlined on fields when inlineEnums is set, tell enum refs
+	// apart from message refs for useAllOf, and check the examples
+	// of enum fields.
+	inlineEnums  bool
+	useAllOf     bool
+	wrapRefs     bool
+	enums        map[string][]string
+	enumReserved map[string][]string
+
+	overlay       map[string]interface{}
+	overlayArrays string
+
+	// cache holds the parsed proto files by resolved path, so files
+	// imported from several places are only parsed once.
+	cache *ParseCache
+
+	// imported holds the files which were walked already.
+	imported map[string]bool
+
+	// report collects the skipped imports, unresolved refs and
+	// warnings of the walk.
+	report *Report
+
+	// filtered holds the definitions left out by onlyPackages.
+	mainPackage  string
+	onlyPackages []string
+	filtered     map[string]bool
+
+	skipWellKnown    bool
+	skippedWellKnown int
 }
 
-func NewWriter(filename, hostname, pathPrefix string) *Writer {
+func NewWriter(filename, hostname, pathPrefix string, opts ...WriterOption) *Writer {
 	if pathPrefix == "" {
 		pathPrefix = "/twirp"
 	}
-	return &Writer{
-		filename:   filename,
-		hostname:   hostname,
-		pathPrefix: pathPrefix,
-		Swagger:    &spec.Swagger{},
+	sw := &Writer{
+		filename:     filename,
+		pathPrefix:   pathPrefix,
+		servicePaths: make(map[string][]string),
+		separator:    "_",
+		fieldsSuffix: true,
+		autoTitles:   true,
+		authRequired: true,
+		Swagger:      &spec.Swagger{},
+
+		int64AsString:        true,
+		paginationTokenField: "next_page_token",
+		parallelImports:      1,
+		cache:                NewParseCache(),
+		report:               NewReport(),
+		enums:                make(map[string][]string),
+		enumReserved:         make(map[string][]string),
+		imported:             make(map[string]bool),
+		filtered:             make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(sw)
 	}
+	// after the options, so the warnings land in a shared report
+	sw.setHost(hostname)
+	return sw
+}
+
+// Reset prepares the writer for another proto file, clearing the
+// generated document and the state of the walk. The options are kept,
+// as are the parse cache and the report, which are shared by the files.
+func (sw *Writer) Reset(filename, hostname string) {
+	sw.Swagger = &spec.Swagger{}
+	sw.filename = filename
+
+	sw.packageName = ""
+	sw.mainPackage = ""
+	sw.currentFile = ""
+	sw.services = nil
+	sw.servicePaths = make(map[string][]string)
+	sw.streams = nil
+	sw.enums = make(map[string][]string)
+	sw.enumReserved = make(map[string][]string)
+	sw.imported = make(map[string]bool)
+	sw.filtered = make(map[string]bool)
+	sw.skippedWellKnown = 0
+	sw.definitionCount = 0
+	sw.optionTitle = ""
+	sw.optionDescription = ""
+	sw.syntax = ""
+
+	sw.setHost(hostname)
 }
 
 func (sw *Writer) Package(pkg *proto.Package) {
@@ -43,53 +193,167 @@ func (sw *Writer) Package(pkg *proto.Package) {
 	sw.Schemes = []string{"http", "https"}
 	sw.Produces = []string{"application/json"}
 	sw.Host = sw.hostname
+	sw.BasePath = sw.basePath
+	if sw.scheme != "" {
+		sw.Schemes = []string{sw.scheme}
+	}
 	sw.Consumes = sw.Produces
+	version := sw.version
+	if version == "" {
+		version = "version not set"
+	}
+	title := sw.title
+	if title == "" {
+		title = path.Base(sw.filename)
+	}
 	sw.Info = &spec.Info{
 		InfoProps: spec.InfoProps{
-			Title:   path.Base(sw.filename),
-			Version: "version not set",
+			Title:          title,
+			Description:    sw.description,
+			Version:        version,
+			Contact:        sw.contact,
+			TermsOfService: sw.termsOfService,
 		},
 	}
+	if sw.generatorInfo {
+		sw.Info.AddExtension("x-generator", sw.generatorExtension())
+	}
+	if sw.generatedBy {
+		sw.Swagger.AddExtension("x-generated-by", sw.generatedByExtension())
+	}
+	for key, value := range sw.extensions {
+		sw.Swagger.AddExtension(key, value)
+	}
 	sw.Swagger.Definitions = make(spec.Definitions)
 	sw.Swagger.Paths = &spec.Paths{
 		Paths: make(map[string]spec.PathItem),
 	}
 
+	if len(sw.responses) > 0 {
+		sw.Swagger.Responses = make(map[string]spec.Response)
+		for name, response := range sw.responses {
+			sw.Swagger.Responses[name] = response
+		}
+	}
+
 	sw.packageName = pkg.Name
+	sw.mainPackage = pkg.Name
 }
 
-func (sw *Writer) Import(i *proto.Import) {
-	// the exclusion here is more about path traversal than it is
-	// about the structure of google proto messages. The annotations
-	// could serve to document a REST API, which goes beyond what
-	// Twitch RPC does out of the box.
-	if strings.Contains(i.Filename, "google/api/annotations.proto") {
+// hasPackage reports if the proto file has a package declaration.
+func hasPackage(definition *proto.Proto) bool {
+	for _, element := range definition.Elements {
+		if _, ok := element.(*proto.Package); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// File options for the document info, defined in twirp_swagger.proto.
+const (
+	titleOption       = "(twirp.swagger.title)"
+	descriptionOption = "(twirp.swagger.description)"
+)
+
+// Option reads the title and description file options, like
+// `option (twirp.swagger.title) = "My API";`. Options of messages,
+// services and fields are ignored.
+func (sw *Writer) Option(o *proto.Option) {
+	if _, ok := o.Parent.(*proto.Proto); !ok {
 		return
 	}
+	switch o.Name {
+	case titleOption:
+		sw.optionTitle = o.Constant.Source
+	case descriptionOption:
+		sw.optionDescription = o.Constant.Source
+	}
+}
+
+// Syntax records the syntax of the main file, emitted as the
+// x-proto-syntax info extension.
+func (sw *Writer) Syntax(s *proto.Syntax) {
+	sw.syntax = s.Value
+}
 
-	// timestamps are handled as string of date-time
-	if strings.Contains(i.Filename, "google/protobuf/timestamp.proto") {
+// applyFileOptions sets the title and description from the file
+// options, which may come after the package statement. The title and
+// description given as options to the writer take precedence. The
+// syntax is added here too, as files without a package are set up
+// before the syntax is walked.
+func (sw *Writer) applyFileOptions() {
+	if sw.Info == nil {
 		return
 	}
+	// files without a syntax statement are proto2
+	syntax := sw.syntax
+	if syntax == "" {
+		syntax = "proto2"
+	}
+	sw.Info.AddExtension("x-proto-syntax", syntax)
+	if sw.optionTitle != "" && sw.title == "" {
+		sw.Info.Title = sw.optionTitle
+	}
+	if sw.optionDescription != "" && sw.description == "" {
+		sw.Info.Description = sw.optionDescription
+	}
+}
+
+func (sw *Writer) Import(i *proto.Import) {
+	if skipImport(i.Filename) {
+		return
+	}
+
+	// Imports are walked recursively regardless of their kind, so the
+	// definitions from `import public` files are emitted as well, and
+	// refs to them are resolved the same as for regular imports.
+	logger := sw.logger().With("import", i.Filename)
+	if i.Kind == "public" {
+		logger.Debug("importing (public)")
+	} else {
+		logger.Debug("importing")
+	}
 
-	log.Debugf("importing %s", i.Filename)
+	// Files imported from several places are walked once, which
+	// also stops circular imports.
+	filename := sw.resolveImport(i.Filename)
+	if sw.imported[filename] {
+		logger.Debug("already imported")
+		return
+	}
+	sw.imported[filename] = true
 
-	definition, err := loadProtoFile(i.Filename)
+	definition, err := sw.loadProtoFile(filename)
 	if err != nil {
-		log.Infof("Can't load %s, err=%s, ignoring (want to make PR?)", i.Filename, err)
+		logger.Info("Can't load import, ignoring (want to make PR?)", "error", err)
+		sw.report.addSkippedFile(SkippedFile{
+			File:   sw.currentFile,
+			Import: i.Filename,
+			Error:  err.Error(),
+		})
 		return
 	}
 
 	oldPackageName := sw.packageName
+	oldCurrentFile := sw.currentFile
+	sw.currentFile = i.Filename
+
+	// imports without a package declaration don't take the package
+	// of the importing file
+	sw.packageName = ""
 
 	withPackage := func(pkg *proto.Package) {
 		sw.packageName = pkg.Name
 	}
 
-	// additional files walked for messages and imports only
-	proto.Walk(definition, proto.WithPackage(withPackage), proto.WithImport(sw.Import), proto.WithMessage(sw.Message))
+	sw.collectEnums(definition)
+
+	// additional files walked for messages, enums and imports only
+	proto.Walk(definition, proto.WithPackage(withPackage), proto.WithImport(sw.Import), proto.WithMessage(sw.Message), proto.WithEnum(sw.Enum))
 
 	sw.packageName = oldPackageName
+	sw.currentFile = oldCurrentFile
 }
 
 func comment(comment *proto.Comment) string {
@@ -103,6 +367,9 @@ func comment(comment *proto.Comment) string {
 		if line == "" {
 			break
 		}
+		if line, _ = splitTags(line); line == "" {
+			continue
+		}
 		result += " " + line
 	}
 	if len(result) > 1 {
@@ -120,7 +387,7 @@ func description(comment *proto.Comment) string {
 
 	result := []string{}
 	for _, line := range comment.Lines {
-		line = strings.TrimSpace(line)
+		line = strings.TrimRight(line, " \t")
 		if line == "" {
 			if grab {
 				break
@@ -128,11 +395,229 @@ func description(comment *proto.Comment) string {
 			grab = true
 			continue
 		}
+		if line, _ = splitTags(line); strings.TrimSpace(line) == "" {
+			continue
+		}
 		if grab {
 			result = append(result, line)
 		}
 	}
-	return strings.Join(result, "\n")
+	return strings.Join(dedent(result), "\n")
+}
+
+// dedent removes the common leading whitespace from lines, keeping
+// the relative indentation, e.g. of nested bullet points.
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	result := make([]string, len(lines))
+	for k, line := range lines {
+		result[k] = line[indent:]
+	}
+	return result
+}
+
+// logger returns a logger with the proto file being processed, or the
+// input file before the walk. The warnings are recorded in the report
+// as well.
+func (sw *Writer) logger() *slog.Logger {
+	handler := &reportHandler{
+		next:   slog.Default().Handler(),
+		report: sw.report,
+	}
+	file := sw.currentFile
+	if file == "" {
+		file = sw.filename
+	}
+	return slog.New(handler).With("file", file)
+}
+
+// defaultValue converts a proto2 default literal into a value of the
+// schema type. 64bit integers are typed as strings and keep the literal,
+// and for enums the default is the value name.
+func defaultValue(literal proto.Literal, fieldType string) interface{} {
+	switch fieldType {
+	case "integer":
+		if v, err := strconv.ParseInt(literal.Source, 0, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(literal.Source, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(literal.Source); err == nil {
+			return v
+		}
+	}
+	return literal.Source
+}
+
+// rpcOptions collects the options declared in the rpc body.
+func rpcOptions(rpc *proto.RPC) []*proto.Option {
+	result := []*proto.Option{}
+	for _, element := range rpc.Elements {
+		if option, ok := element.(*proto.Option); ok {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// hasOption returns true if the named option is set to value.
+func hasOption(options []*proto.Option, name, value string) bool {
+	for _, option := range options {
+		if option.Name == name && option.Constant.Source == value {
+			return true
+		}
+	}
+	return false
+}
+
+// visibility returns the value of the configured visibility option,
+// if it's set on the element.
+func (sw *Writer) visibility(options []*proto.Option) (string, bool) {
+	if sw.visibilityOption == "" {
+		return "", false
+	}
+	if option, ok := findOption(options, sw.visibilityOption); ok {
+		return option.Constant.Source, true
+	}
+	return "", false
+}
+
+// findOption finds an option by name, ignoring the parentheses
+// around custom option names.
+func findOption(options []*proto.Option, name string) (*proto.Option, bool) {
+	want := strings.Trim(name, "()")
+	for _, option := range options {
+		if strings.Trim(option.Name, "()") == want {
+			return option, true
+		}
+	}
+	return nil, false
+}
+
+// definitionName returns the definition key for a message type. Types
+// without a package are prefixed with the current package name, and
+// qualified types (e.g. `apm.v1.Message`) are split on the last dot.
+// Files without a package declaration key their types by name only.
+func (sw *Writer) definitionName(typeName string) string {
+	// fully qualified names may have a leading dot, `.pkg.Message`
+	typeName = strings.TrimPrefix(typeName, ".")
+	idx := strings.LastIndex(typeName, ".")
+	if idx < 0 {
+		if sw.packageName == "" {
+			return typeName
+		}
+		return sw.packageName + sw.separator + typeName
+	}
+	return typeName[:idx] + sw.separator + typeName[idx+1:]
+}
+
+// source formats the position of an element as file:line.
+func (sw *Writer) source(pos scanner.Position) string {
+	return fmt.Sprintf("%s:%d", sw.currentFile, pos.Line)
+}
+
+// includeService reports if the service has one of the tags selected
+// with WithServiceTags. All services are included by default, and
+// none with WithDefinitionsOnly.
+func (sw *Writer) includeService(srv *proto.Service) bool {
+	if sw.definitionsOnly {
+		return false
+	}
+	if len(sw.serviceTags) == 0 {
+		return true
+	}
+	for _, tag := range parseServiceTags(srv.Comment) {
+		for _, selected := range sw.serviceTags {
+			if tag == selected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (sw *Writer) Service(srv *proto.Service) {
+	if !sw.includeService(srv) {
+		return
+	}
+	// services without a comment are described by their package
+	tagDescription := strings.TrimSpace(comment(srv.Comment) + "\n\n" + description(srv.Comment))
+	if tagDescription == "" && sw.packageName != "" {
+		tagDescription = "Package: " + sw.packageName
+	}
+	sw.Swagger.Tags = append(sw.Swagger.Tags, spec.Tag{
+		TagProps: spec.TagProps{
+			Name:        srv.Name,
+			Description: tagDescription,
+		},
+	})
+}
+
+// validateVersion checks that the version has a major version for
+// WithVersionInPath, as the paths would silently lose the prefix.
+func (sw *Writer) validateVersion() error {
+	if !sw.versionInPath {
+		return nil
+	}
+	if _, ok := majorVersion(sw.version); !ok {
+		return fmt.Errorf("%w: %q, required by version_in_path", ErrInvalidVersion, sw.version)
+	}
+	return nil
+}
+
+// majorVersion extracts the major version from a semver string,
+// e.g. `1.2.3` and `v1.2` both return `1`.
+func majorVersion(version string) (string, bool) {
+	major := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0]
+	if _, err := strconv.Atoi(major); err != nil {
+		return "", false
+	}
+	return major, true
+}
+
+// rpcTags returns the tags for an rpc. The service name is used unless
+// the comment has a `; tag:Billing,Invoices` tag. Tags which aren't
+// declared yet get added to the document.
+func (sw *Writer) rpcTags(rpc *proto.RPC, parent *proto.Service) []string {
+	value, ok := commentTags(rpc.Comment)["tag"]
+	if !ok {
+		return []string{parent.Name}
+	}
+
+	result := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	if len(result) == 0 {
+		return []string{parent.Name}
+	}
+
+	for _, name := range result {
+		if !sw.hasTag(name) {
+			sw.Swagger.Tags = append(sw.Swagger.Tags, spec.NewTag(name, "", nil))
+		}
+	}
+	return result
+}
+
+func (sw *Writer) hasTag(name string) bool {
+	for _, tag := range sw.Swagger.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (sw *Writer) RPC(rpc *proto.RPC) {
@@ -140,44 +625,60 @@ func (sw *Writer) RPC(rpc *proto.RPC) {
 	if !ok {
 		panic("parent is not proto.service")
 	}
+	if !sw.includeService(parent) {
+		return
+	}
 
-	pathName := filepath.Join("/"+sw.pathPrefix+"/", sw.packageName+"."+parent.Name, rpc.Name)
-	// pathName := fmt.Sprintf("/twirp/%s.%s/%s", sw.packageName, parent.Name, rpc.Name)
+	pathPrefix := "/" + strings.Trim(sw.pathPrefix, "/")
+	if pathPrefix == "/" {
+		pathPrefix = ""
+	}
+	// twirp routes services without a package by the service name
+	serviceName := parent.Name
+	if sw.packageName != "" {
+		serviceName = sw.packageName + "." + parent.Name
+	}
+	pathName := fmt.Sprintf("%s/%s/%s", pathPrefix, serviceName, rpc.Name)
+	if sw.versionInPath {
+		if major, ok := majorVersion(sw.version); ok {
+			pathName = fmt.Sprintf("/v%s%s", major, pathName)
+		}
+	}
+	if apiPrefix := strings.Trim(sw.apiPrefix, "/"); apiPrefix != "" {
+		pathName = "/" + apiPrefix + pathName
+	}
 
-	sw.Swagger.Paths.Paths[pathName] = spec.PathItem{
-		PathItemProps: spec.PathItemProps{
-			Post: &spec.Operation{
-				OperationProps: spec.OperationProps{
-					ID:      rpc.Name,
-					Tags:    []string{parent.Name},
-					Summary: comment(rpc.Comment),
-					Responses: &spec.Responses{
-						ResponsesProps: spec.ResponsesProps{
-							StatusCodeResponses: map[int]spec.Response{
-								200: spec.Response{
-									ResponseProps: spec.ResponseProps{
-										Description: "A successful response.",
-										Schema: &spec.Schema{
-											SchemaProps: spec.SchemaProps{
-												Ref: spec.MustCreateRef(fmt.Sprintf("#/definitions/%s_%s", sw.packageName, rpc.ReturnsType)),
-											},
-										},
+	operation := &spec.Operation{
+		OperationProps: spec.OperationProps{
+			ID:          rpc.Name,
+			Tags:        sw.rpcTags(rpc, parent),
+			Summary:     comment(rpc.Comment),
+			Description: description(rpc.Comment),
+			Responses: &spec.Responses{
+				ResponsesProps: spec.ResponsesProps{
+					StatusCodeResponses: map[int]spec.Response{
+						200: spec.Response{
+							ResponseProps: spec.ResponseProps{
+								Description: "A successful response.",
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: spec.MustCreateRef("#/definitions/" + sw.definitionName(rpc.ReturnsType)),
 									},
 								},
 							},
 						},
 					},
-					Parameters: []spec.Parameter{
-						spec.Parameter{
-							ParamProps: spec.ParamProps{
-								Name:     "body",
-								In:       "body",
-								Required: true,
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: spec.MustCreateRef(fmt.Sprintf("#/definitions/%s_%s", sw.packageName, rpc.RequestType)),
-									},
-								},
+				},
+			},
+			Parameters: []spec.Parameter{
+				spec.Parameter{
+					ParamProps: spec.ParamProps{
+						Name:     "body",
+						In:       "body",
+						Required: true,
+						Schema: &spec.Schema{
+							SchemaProps: spec.SchemaProps{
+								Ref: spec.MustCreateRef("#/definitions/" + sw.definitionName(rpc.RequestType)),
 							},
 						},
 					},
@@ -185,10 +686,90 @@ func (sw *Writer) RPC(rpc *proto.RPC) {
 			},
 		},
 	}
+
+	logger := sw.logger().With("rpc", rpc.Name)
+	for code, text := range parseResponseAnnotations(rpc.Comment, logger) {
+		response := operation.Responses.StatusCodeResponses[code]
+		response.Description = text
+		operation.Responses.StatusCodeResponses[code] = response
+	}
+	sw.addGlobalResponses(operation)
+
+	// rpcs with another content type than JSON return the body as is
+	if produces := commentTags(rpc.Comment)["produces"]; produces != "" {
+		operation.Produces = []string{produces}
+		response := operation.Responses.StatusCodeResponses[200]
+		response.Schema = &spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:   spec.StringOrArray([]string{"string"}),
+				Format: "binary",
+			},
+		}
+		operation.Responses.StatusCodeResponses[200] = response
+	}
+
+	if sw.gatewayOptions {
+		sw.applyGatewayOperation(operation, rpcOptions(rpc))
+	}
+
+	if _, ok := commentTags(parent.Comment)["unauthenticated"]; ok {
+		operation.Security = []map[string][]string{}
+	}
+
+	if _, ok := commentTags(rpc.Comment)["deprecated"]; ok || hasOption(rpcOptions(rpc), "deprecated", "true") {
+		operation.Deprecated = true
+	}
+
+	if visibility, ok := sw.visibility(rpcOptions(rpc)); ok {
+		operation.AddExtension("x-visibility", visibility)
+	}
+	if rateLimit := parseRateLimit(rpc.Comment, logger); rateLimit != nil {
+		operation.AddExtension("x-ratelimit", rateLimit)
+	}
+	if date, ok := dateAnnotation(rpc.Comment, "deprecated_since", logger); ok {
+		operation.AddExtension("x-deprecated-since", date)
+	}
+	// the sunset date is the RFC 8594 `Sunset` header of some gateways
+	if date, ok := dateAnnotation(rpc.Comment, "sunset", logger); ok {
+		operation.AddExtension("x-sunset", date)
+	}
+	if sw.emitSourceInfo {
+		operation.AddExtension("x-proto-source", sw.source(rpc.Position))
+	}
+
+	sw.Swagger.Paths.Paths[pathName] = spec.PathItem{
+		PathItemProps: spec.PathItemProps{
+			Post: operation,
+		},
+	}
+
+	if rpc.StreamsRequest || rpc.StreamsReturns {
+		sw.streams = append(sw.streams, streamingRPC{
+			pathName: pathName,
+			id:       rpc.Name,
+			summary:  operation.Summary,
+			request:  sw.definitionName(rpc.RequestType),
+			response: sw.definitionName(rpc.ReturnsType),
+		})
+	}
+
+	if _, ok := sw.servicePaths[parent.Name]; !ok {
+		sw.services = append(sw.services, parent.Name)
+	}
+	sw.servicePaths[parent.Name] = append(sw.servicePaths[parent.Name], pathName)
 }
 
 func (sw *Writer) Message(msg *proto.Message) {
-	definitionName := fmt.Sprintf("%s_%s", sw.packageName, msg.Name)
+	definitionName := sw.definitionName(msg.Name)
+	if !sw.includePackage() {
+		sw.filtered[definitionName] = true
+		return
+	}
+	if sw.skipWellKnown && sw.packageName == "google.protobuf" {
+		sw.skippedWellKnown++
+		return
+	}
+	logger := sw.logger().With("message", msg.Name)
 
 	schemaProps := make(map[string]spec.Schema)
 
@@ -209,147 +790,306 @@ func (sw *Writer) Message(msg *proto.Message) {
 		return -1, false
 	}
 
-	var fieldOrder = []string{}
-
-	allFields := msg.Elements
+	var (
+		fieldOrder     = []string{}
+		requiredFields = []string{}
+	)
 
+	// Oneof fields are unpacked in place, so they keep their source
+	// position in the field order. The proto grammar doesn't allow
+	// repeated, map or nested oneof members, so members are always
+	// single values. Options and comments in a oneof are skipped with
+	// the other elements, and proto2 groups are skipped with a warning.
+	allFields := []proto.Visitee{}
 	for _, element := range msg.Elements {
 		switch val := element.(type) {
 		case *proto.Oneof:
-			// We're unpacking val.Elements into the field list,
-			// which may or may not be correct. The oneof semantics
-			// likely bring in edge-cases.
 			allFields = append(allFields, val.Elements...)
 		default:
-			// No need to unpack for *proto.NormalField,...
-			log.Debugf("prepare: uknown field type: %T", element)
+			allFields = append(allFields, element)
 		}
 	}
 
-	addField := func(field *proto.Field, repeated bool) {
+	// addField adds a field to the schema properties. For map fields,
+	// keyType is the type of the map keys, and field.Type the type of
+	// the values.
+	addField := func(field *proto.Field, repeated bool, keyType string) {
+		if hidden(field) {
+			return
+		}
+
 		var (
-			fieldTitle       = comment(field.Comment)
-			fieldDescription = description(field.Comment)
+			fieldTitle       = comment(fieldComment(field))
+			fieldDescription = description(fieldComment(field))
 			fieldName        = field.Name
-			fieldType        = field.Type
-			fieldFormat      = field.Type
+			fieldType        = strings.TrimPrefix(field.Type, ".")
+			fieldFormat      = fieldType
 		)
 
 		p, ok := typeAliases[fieldType]
 		if ok {
 			fieldType = p.Type
 			fieldFormat = p.Format
+			if fieldDescription == "" {
+				fieldDescription = p.Description
+			}
+		}
+		// 64bit integers are strings by default, as JavaScript
+		// numbers lose precision above 2^53.
+		if !sw.int64AsString && fieldType == "string" && strings.HasSuffix(fieldFormat, "int64") {
+			fieldType = "integer"
 		}
 		if fieldType == fieldFormat {
 			fieldFormat = ""
 		}
 
 		fieldOrder = append(fieldOrder, fieldName)
+		if _, ok := commentTags(fieldComment(field))["required"]; ok {
+			if _, found := find(requiredFields, fieldName); !found {
+				requiredFields = append(requiredFields, fieldName)
+			}
+		}
 
+		// valueSchema is the schema of a single value, which is
+		// either a scalar type or a reference to a definition.
+		var valueSchema spec.Schema
 		if _, ok := find(allowedValues, fieldType); ok {
-			fieldSchema := spec.Schema{
+			valueSchema = spec.Schema{
 				SchemaProps: spec.SchemaProps{
-					Title:       fieldTitle,
-					Description: fieldDescription,
-					Type:        spec.StringOrArray([]string{fieldType}),
-					Format:      fieldFormat,
+					Type:   spec.StringOrArray([]string{fieldType}),
+					Format: fieldFormat,
 				},
 			}
-			if repeated {
-				fieldSchema.Title = ""
-				fieldSchema.Description = ""
-				fieldSchema.Format = ""
-				schemaProps[fieldName] = spec.Schema{
-					SchemaProps: spec.SchemaProps{
-						Title:       fieldTitle,
-						Description: fieldDescription,
-						Type:        spec.StringOrArray([]string{"array"}),
-						Format:      fieldFormat,
-						Items: &spec.SchemaOrArray{
-							Schema: &fieldSchema,
-						},
-					},
-				}
-			} else {
-				schemaProps[fieldName] = fieldSchema
+			// 64bit integers are encoded as strings
+			isInteger := fieldType == "integer" || strings.HasSuffix(fieldFormat, "int64")
+			if values, ok := commentTags(fieldComment(field))["enum"]; ok && isInteger {
+				sw.enumTag(&valueSchema, logger.With("field", field.Name), values, fieldType == "string")
+			}
+			if fieldType == "string" {
+				sw.formatTag(&valueSchema, commentTags(fieldComment(field)))
+			}
+		} else if enumSchema, ok := sw.inlineEnum(fieldType); ok && sw.inlineEnums {
+			valueSchema = enumSchema
+		} else {
+			valueSchema = spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Ref: spec.MustCreateRef("#/definitions/" + sw.definitionName(fieldType)),
+				},
 			}
-			return
 		}
 
-		// Prefix rich type with package name
-		if !strings.Contains(fieldType, ".") {
-			fieldType = sw.packageName + "_" + fieldType
+		if value, ok := commentTags(fieldComment(field))["range"]; ok {
+			rangeTag(&valueSchema, logger.With("field", field.Name), value)
 		}
-		ref := fmt.Sprintf("#/definitions/%s", fieldType)
 
-		if repeated {
-			schemaProps[fieldName] = spec.Schema{
+		var fieldSchema spec.Schema
+		switch {
+		case repeated:
+			// the format belongs to the items, e.g. repeated bytes
+			// is an array of base64 encoded strings.
+			fieldSchema = spec.Schema{
 				SchemaProps: spec.SchemaProps{
-					Title:       fieldTitle,
-					Description: fieldDescription,
-					Type:        spec.StringOrArray([]string{"array"}),
+					Type: spec.StringOrArray([]string{"array"}),
 					Items: &spec.SchemaOrArray{
-						Schema: &spec.Schema{
-							SchemaProps: spec.SchemaProps{
-								Ref: spec.MustCreateRef(ref),
-							},
-						},
+						Schema: &valueSchema,
 					},
 				},
 			}
-			return
+		case keyType != "":
+			// JSON object keys are always strings, so other key
+			// types are noted in the description.
+			if keyType != "string" {
+				note := fmt.Sprintf("Keys are %s values serialized as strings.", keyType)
+				fieldDescription = strings.TrimSpace(fieldDescription + "\n\n" + note)
+			}
+			fieldSchema = spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Type: spec.StringOrArray([]string{"object"}),
+					AdditionalProperties: &spec.SchemaOrBool{
+						Allows: true,
+						Schema: &valueSchema,
+					},
+				},
+			}
+		default:
+			fieldSchema = valueSchema
 		}
-		schemaProps[fieldName] = spec.Schema{
-			SchemaProps: spec.SchemaProps{
-				Title:       fieldTitle,
-				Description: fieldDescription,
-				Ref:         spec.MustCreateRef(ref),
-			},
+		fieldSchema.Title = fieldTitle
+		fieldSchema.Description = fieldDescription
+
+		// the example is set on the field level, so for repeated
+		// fields and maps it's the whole array or object.
+		if example, ok := commentTags(fieldComment(field))["example"]; ok {
+			exampleType := fieldType
+			values, isEnum := sw.enums[sw.definitionName(fieldType)]
+			switch {
+			case keyType != "":
+				exampleType = "object"
+				isEnum = false
+			case isEnum:
+				exampleType = "string"
+			}
+			value := exampleTag(example, exampleType, repeated)
+			if invalid, ok := enumExample(value, values); isEnum && !ok {
+				logger.Warn("ignoring example which isn't an enum value", "field", field.Name, "example", invalid, "values", strings.Join(values, ","))
+			} else {
+				fieldSchema.Example = value
+			}
+		}
+
+		if option, ok := findOption(field.Options, "default"); ok && !repeated {
+			fieldSchema.Default = defaultValue(option.Constant, fieldType)
+		}
+
+		if visibility, ok := sw.visibility(field.Options); ok {
+			fieldSchema.AddExtension("x-visibility", visibility)
+		}
+
+		switch sw.fieldOrder {
+		case FieldOrderDeclaration:
+			fieldSchema.AddExtension("x-order", len(fieldOrder)-1)
+		case FieldOrderProtoNumber:
+			fieldSchema.AddExtension("x-order", field.Sequence)
 		}
+
+		schemaProps[fieldName] = fieldSchema
 	}
 
 	for _, element := range allFields {
 		switch val := element.(type) {
-		case *proto.Comment:
-		case *proto.Oneof:
-			// Nothing.
+		case *proto.Comment, *proto.Option:
 		case *proto.OneOfField:
-			addField(val.Field, false)
+			addField(val.Field, false, "")
 		case *proto.MapField:
-			addField(val.Field, false)
+			addField(val.Field, false, val.KeyType)
 		case *proto.NormalField:
-			addField(val.Field, val.Repeated)
+			// proto2 required fields are required in the schema too
+			if val.Required && !hidden(val.Field) {
+				requiredFields = append(requiredFields, val.Name)
+			}
+			addField(val.Field, val.Repeated, "")
+		case *proto.Group:
+			logger.Warn("skipping group, groups are not supported", "field", val.Name)
 		default:
-			log.Infof("Unknown field type: %T", element)
+			logger.Info("Unknown field type", "type", fmt.Sprintf("%T", element))
 		}
 	}
 
 	schemaDesc := description(msg.Comment)
-	if len(fieldOrder) > 0 {
+	if sw.fieldsSuffix && len(fieldOrder) > 0 {
 		// This is required to infer order, as json object keys
 		// don't keep their order. Should have been an array.
 		schemaDesc = schemaDesc + "\n\nFields: " + strings.Join(fieldOrder, ", ")
 	}
 
-	sw.Swagger.Definitions[definitionName] = spec.Schema{
+	title := comment(msg.Comment)
+	if title == "" && sw.autoTitles {
+		title = humanName(msg.Name)
+	}
+
+	schema := spec.Schema{
 		SchemaProps: spec.SchemaProps{
-			Title:       comment(msg.Comment),
+			Title:       title,
 			Description: strings.TrimSpace(schemaDesc),
 			Type:        spec.StringOrArray([]string{"object"}),
 			Properties:  schemaProps,
 		},
 	}
+	if len(requiredFields) > 0 {
+		schema.Required = requiredFields
+	}
+	if sw.useAllOf {
+		sw.composeAllOf(&schema)
+	}
+	if sw.wrapRefs {
+		wrapRefs(&schema)
+	}
+	// Only the message definitions are closed, the map fields keep
+	// their additionalProperties value schema. An allOf can't be
+	// closed, as it would reject the properties of its parts.
+	if sw.strictObjects && len(schema.AllOf) == 0 {
+		schema.AdditionalProperties = &spec.SchemaOrBool{Allows: false}
+	}
+	if value, ok := commentTags(msg.Comment)["example"]; ok {
+		var example interface{}
+		if err := json.Unmarshal([]byte(value), &example); err != nil {
+			logger.Warn("ignoring invalid example", "example", value, "error", err)
+		} else {
+			schema.Example = example
+		}
+	}
+	schema.AddExtension("x-proto-file", sw.protoFile())
+	if sw.emitSourceInfo {
+		schema.AddExtension("x-proto-source", sw.source(msg.Position))
+	}
+	if sw.gatewayOptions {
+		sw.applyGatewaySchema(&schema, messageOptions(msg))
+	}
+	if option, ok := findOption(messageOptions(msg), "google.api.resource"); ok {
+		if resourceType, ok := option.Constant.OrderedMap.Get("type"); ok {
+			schema.AddExtension("x-resource-type", resourceType.Source)
+		}
+	}
+	if ids := annotations(msg.Comment, "schema-id"); len(ids) > 0 {
+		schema.AddExtension("x-schema-id", ids[0])
+		if sw.schemaRegistryURL != "" {
+			schema.AddExtension("x-schema-registry", sw.schemaRegistryURL)
+		}
+	}
+
+	// definitions are numbered in the order they are walked, with
+	// imports walked where they are declared.
+	if sw.definitionOrder {
+		schema.AddExtension("x-order", sw.definitionCount)
+		sw.definitionCount++
+	}
+
+	sw.Swagger.Definitions[definitionName] = schema
+}
+
+// deprecateServices marks the tags of services where all the
+// operations are deprecated with `x-deprecated`.
+func (sw *Writer) deprecateServices() {
+	for k, tag := range sw.Swagger.Tags {
+		pathNames := sw.servicePaths[tag.Name]
+		if len(pathNames) == 0 {
+			continue
+		}
+		deprecated := true
+		for _, pathName := range pathNames {
+			if operation := sw.Swagger.Paths.Paths[pathName].Post; operation == nil || !operation.Deprecated {
+				deprecated = false
+				break
+			}
+		}
+		if !deprecated {
+			continue
+		}
+		sw.Swagger.Tags[k].AddExtension("x-deprecated", true)
+		if sw.warnDeprecatedServices {
+			sw.logger().Warn("all rpcs are deprecated", "service", tag.Name)
+		}
+	}
 }
 
 func (sw *Writer) Handlers() []proto.Handler {
 	return []proto.Handler{
 		proto.WithPackage(sw.Package),
+		proto.WithService(sw.Service),
 		proto.WithRPC(sw.RPC),
 		proto.WithMessage(sw.Message),
+		proto.WithEnum(sw.Enum),
 		proto.WithImport(sw.Import),
+		proto.WithOption(sw.Option),
+		func(v proto.Visitee) {
+			if s, ok := v.(*proto.Syntax); ok {
+				sw.Syntax(s)
+			}
+		},
 	}
 }
 
+// Save writes the document as UTF-8 JSON without a byte order mark.
 func (sw *Writer) Save(filename string) error {
 	body := sw.Get()
 	return ioutil.WriteFile(filename, body, os.ModePerm^0111)
@@ -361,27 +1101,114 @@ func (sw *Writer) Get() []byte {
 }
 
 func (sw *Writer) WalkFile() error {
-	definition, err := loadProtoFile(sw.filename)
+	if err := sw.validateContact(); err != nil {
+		return err
+	}
+	if err := sw.validateVersion(); err != nil {
+		return err
+	}
+
+	definition, err := sw.loadProtoFile(sw.filename)
 	if err != nil {
 		return err
 	}
 
+	sw.currentFile = sw.filename
+	sw.imported[sw.filename] = true
+
+	if sw.parallelImports > 1 {
+		sw.parseImports(definition)
+	}
+
+	// files without a package declaration still need the document
+	// set up, their definitions are keyed without a package prefix
+	if !hasPackage(definition) {
+		sw.Package(&proto.Package{})
+	}
+
+	sw.collectEnums(definition)
+
 	// main file for all the relevant info
 	proto.Walk(definition, sw.Handlers()...)
 
-	if len(sw.Swagger.Paths.Paths) == 0 {
+	sw.applyFileOptions()
+	sw.deprecateServices()
+	sw.markPageable()
+
+	if sw.skippedWellKnown > 0 {
+		sw.logger().Warn("skipped google.protobuf definitions, refs to them won't resolve in strict validators", "count", sw.skippedWellKnown)
+	}
+
+	if len(sw.filtered) > 0 {
+		if err := sw.checkFilteredRefs(); err != nil {
+			return err
+		}
+	}
+
+	if len(sw.renames) > 0 {
+		if err := sw.applyRenames(); err != nil {
+			return err
+		}
+	}
+
+	if len(sw.overlay) > 0 {
+		if err := sw.applyOverlay(); err != nil {
+			return err
+		}
+	}
+	sw.applyAuth()
+
+	if err := sw.checkUnresolvedRefs(); err != nil {
+		return err
+	}
+
+	if sw.lint {
+		if err := sw.Lint(); err != nil {
+			return err
+		}
+	}
+
+	if len(sw.Swagger.Paths.Paths) == 0 && !sw.definitionsOnly {
 		return ErrNoServiceDefinition
 	}
 	return nil
 }
 
-func loadProtoFile(filename string) (*proto.Proto, error) {
-	reader, err := os.Open(filename)
+// openProtoFile opens proto files for parsing, tests replace it to
+// count the parsed files.
+var openProtoFile = os.Open
+
+// loadProtoFile parses a proto file, or returns it from the cache if
+// it was parsed before.
+func (sw *Writer) loadProtoFile(filename string) (*proto.Proto, error) {
+	if definition, ok := sw.cache.get(filename); ok {
+		return definition, nil
+	}
+
+	reader, err := openProtoFile(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
 
-	parser := proto.NewParser(reader)
-	return parser.Parse()
+	parser := proto.NewParser(skipBOM(bufio.NewReader(reader)))
+	definition, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	sw.cache.put(filename, definition)
+	return definition, nil
+}
+
+// utf8BOM is the byte order mark some Windows editors prepend to files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// skipBOM drops a leading byte order mark, which would otherwise end up
+// in the first comment or break the parser.
+func skipBOM(reader *bufio.Reader) *bufio.Reader {
+	if prefix, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+	return reader
 }
@@ -0,0 +1,783 @@
+package swagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-bridget/twirp-swagger-gen/internal/asyncapi"
+	"github.com/go-openapi/spec"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestWriter_Golden(t *testing.T) {
+	testCases := []struct {
+		name   string
+		golden string
+		opts   []WriterOption
+	}{
+		{name: "simple_service"},
+		{name: "simple_service", golden: "simple_service_title", opts: []WriterOption{WithTitle("Simple API"), WithDescription("A simple API for things.")}},
+		{name: "nested_messages"},
+		{name: "enums"},
+		{name: "enums", golden: "enums_strict", opts: []WriterOption{WithStrict(true), WithLint(true)}},
+		{name: "enums", golden: "enums_inline", opts: []WriterOption{WithInlineEnums(true)}},
+		{name: "map_fields"},
+		{name: "map_fields", golden: "map_fields_strict", opts: []WriterOption{WithStrictObjects(true)}},
+		{name: "map_fields", golden: "map_fields_no_titles", opts: []WriterOption{WithAutoTitles(false)}},
+		{name: "map_fields", golden: "map_fields_definitions_only", opts: []WriterOption{WithDefinitionsOnly(true)}},
+		{name: "map_messages", opts: []WriterOption{WithStrict(true), WithLint(true)}},
+		{name: "oneof_fields"},
+		{name: "oneof_fields", golden: "oneof_fields_declaration_order", opts: []WriterOption{WithFieldOrder(FieldOrderDeclaration)}},
+		{name: "oneof_fields", golden: "oneof_fields_proto_number_order", opts: []WriterOption{WithFieldOrder(FieldOrderProtoNumber)}},
+		{name: "imported_types"},
+		{name: "imported_types", opts: []WriterOption{WithParallelImports(4)}},
+		{name: "imported_types", golden: "imported_types_separator", opts: []WriterOption{WithDefinitionSeparator("__"), WithStrict(true), WithLint(true)}},
+		{name: "renamed_types", opts: []WriterOption{WithAllOf(true), WithLint(true), WithRenames(map[string]string{"dep.Request": "GetRequest", "dep_Shared": "Shared", "renamed.Response": "GetResponse"})}},
+		{name: "defaults"},
+		{name: "hidden_fields"},
+		{name: "string_formats"},
+		{name: "diamond"},
+		{name: "diamond", golden: "diamond_definition_order", opts: []WriterOption{WithDefinitionOrder(true)}},
+		{name: "import_cycle"},
+		{name: "chain_a", opts: []WriterOption{WithStrict(true), WithLint(true)}},
+		{name: "bom"},
+		{name: "bom", golden: "bom_extensions", opts: []WriterOption{WithExtensions(map[string]interface{}{"x-audience": "public", "x-owner": map[string]interface{}{"team": "api"}})}},
+		{name: "bom", golden: "bom_contact", opts: []WriterOption{WithContact("API Team", "api@example.com", "https://example.com/support"), WithTermsOfService("https://example.com/terms")}},
+		{name: "leading_dot", opts: []WriterOption{WithLint(true)}},
+		{name: "well_known_types", opts: []WriterOption{WithLint(true)}},
+		{name: "skip_well_known", opts: []WriterOption{WithProtoPaths([]string{"testdata/well_known"})}},
+		{name: "skip_well_known", golden: "skip_well_known_skipped", opts: []WriterOption{WithProtoPaths([]string{"testdata/well_known"}), WithSkipWellKnown(true)}},
+		{name: "only_packages", opts: []WriterOption{WithOnlyPackages([]string{"common"})}},
+		{name: "field_examples"},
+		{name: "integer_formats"},
+		{name: "integer_formats", golden: "integer_formats_int64", opts: []WriterOption{WithInt64AsString(false)}},
+		{name: "embedded_header", golden: "embedded_header_allof", opts: []WriterOption{WithAllOf(true)}},
+		{name: "ranges"},
+		{name: "sunset"},
+		{name: "file_options"},
+		{name: "file_options", golden: "file_options_flags", opts: []WriterOption{WithTitle("Flag API")}},
+		{name: "enum_examples"},
+		{name: "enum_examples", golden: "enum_examples_inline", opts: []WriterOption{WithInlineEnums(true)}},
+		{name: "inline_comments"},
+		{name: "indented_comments"},
+		{name: "pageable"},
+		{name: "bytes_fields", opts: []WriterOption{WithLint(true)}},
+		{name: "repeated_enums", opts: []WriterOption{WithLint(true)}},
+		{name: "repeated_enums", golden: "repeated_enums_inline", opts: []WriterOption{WithInlineEnums(true), WithLint(true)}},
+		{name: "produces", opts: []WriterOption{WithLint(true)}},
+		{name: "proto2_syntax"},
+		{name: "proto2_groups", opts: []WriterOption{WithLint(true)}},
+		{name: "no_package", opts: []WriterOption{WithInlineEnums(true), WithLint(true)}},
+		{name: "pageable", golden: "pageable_disabled", opts: []WriterOption{WithPaginationTokenField("")}},
+		{name: "ref_descriptions"},
+		{name: "ref_descriptions", golden: "ref_descriptions_wrapped", opts: []WriterOption{WithWrapRefs(true), WithLint(true)}},
+		{name: "service_tags"},
+		{name: "service_tags", golden: "service_tags_public_beta", opts: []WriterOption{WithServiceTags([]string{"public", "beta"})}},
+		{name: "include/app/app_service", golden: "proto_file_relative", opts: []WriterOption{WithProtoPaths([]string{"testdata/include/app"})}},
+		{name: "proto_path", opts: []WriterOption{WithProtoPaths([]string{"testdata/include/vendor", "testdata/include/app"})}},
+		{name: "string_formats", golden: "string_formats_patterns", opts: []WriterOption{WithFormatPatterns(true)}},
+	}
+
+	for _, tc := range testCases {
+		golden := tc.golden
+		if golden == "" {
+			golden = tc.name
+		}
+		t.Run(golden, func(t *testing.T) {
+			assertGolden(t, tc.name, golden, tc.opts...)
+		})
+	}
+}
+
+func TestWriter_FieldExamples(t *testing.T) {
+	writer := NewWriter("testdata/field_examples.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+	properties := writer.Swagger.Definitions["examples_Item"].Properties
+
+	testCases := []struct {
+		field string
+		want  interface{}
+	}{
+		{"id", "abc-123"},
+		{"size", int64(42)},
+		{"price", 9.99},
+		{"available", true},
+		{"labels", []interface{}{"red", "blue"}},
+		{"scores", []interface{}{int64(7)}},
+		{"owner", map[string]interface{}{"name": "Jane"}},
+		{"previous_owners", []interface{}{map[string]interface{}{"name": "John"}}},
+		{"attributes", map[string]interface{}{"color": "red"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.field, func(t *testing.T) {
+			property := properties[tc.field]
+			if !reflect.DeepEqual(property.Example, tc.want) {
+				t.Errorf("got example %#v, want %#v", property.Example, tc.want)
+			}
+			if property.Items != nil && property.Items.Schema.Example != nil {
+				t.Errorf("unexpected example on items: %#v", property.Items.Schema.Example)
+			}
+			if property.AdditionalProperties != nil && property.AdditionalProperties.Schema.Example != nil {
+				t.Errorf("unexpected example on map values: %#v", property.AdditionalProperties.Schema.Example)
+			}
+		})
+	}
+}
+
+func TestWriter_OneofFields(t *testing.T) {
+	writer := NewWriter("testdata/oneof_fields.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+	schema := writer.Swagger.Definitions["oneofs_Request"]
+
+	testCases := []struct {
+		field    string
+		typeName string
+		ref      string
+	}{
+		{field: "name", typeName: "string"},
+		{field: "number", typeName: "string"},
+		{field: "none", ref: "#/definitions/oneofs_Empty"},
+		{field: "kind", ref: "#/definitions/oneofs_Kind"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.field, func(t *testing.T) {
+			property, ok := schema.Properties[tc.field]
+			if !ok {
+				t.Fatalf("missing oneof member %s", tc.field)
+			}
+			if property.Items != nil || property.AdditionalProperties != nil {
+				t.Errorf("oneof member isn't a single value: %+v", property.SchemaProps)
+			}
+			if tc.typeName != "" && !property.Type.Contains(tc.typeName) {
+				t.Errorf("got type %v, want %s", property.Type, tc.typeName)
+			}
+			if got := property.Ref.String(); got != tc.ref {
+				t.Errorf("got ref %q, want %q", got, tc.ref)
+			}
+		})
+	}
+
+	want := "Fields: id, name, number, none, kind, active"
+	if schema.Description != want {
+		t.Errorf("got description %q, want %q", schema.Description, want)
+	}
+}
+
+func TestWriter_GeneratedBy(t *testing.T) {
+	writer := NewWriter("testdata/simple_service.proto", "api.example.com", "/twirp", WithGeneratedBy(true), WithGeneratorVersion("1.2.3"))
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	value, ok := writer.Swagger.Extensions["x-generated-by"]
+	if !ok {
+		t.Fatal("missing x-generated-by extension")
+	}
+	generatedBy := value.(map[string]string)
+	if generatedBy["tool"] != "twirp-swagger-gen" || generatedBy["version"] != "1.2.3" || generatedBy["proto_file"] != "testdata/simple_service.proto" {
+		t.Errorf("unexpected x-generated-by: %v", generatedBy)
+	}
+	if _, err := time.Parse(time.RFC3339, generatedBy["timestamp"]); err != nil {
+		t.Errorf("timestamp isn't RFC3339: %s", err)
+	}
+}
+
+func TestWriter_Overlay(t *testing.T) {
+	overlay, err := LoadOverlay("testdata/overlay.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("append", func(t *testing.T) {
+		assertGolden(t, "simple_service", "overlay_append", WithOverlay(overlay, OverlayArraysAppend))
+	})
+	t.Run("replace", func(t *testing.T) {
+		assertGolden(t, "simple_service", "overlay_replace", WithOverlay(overlay, OverlayArraysReplace))
+	})
+	t.Run("no_auth", func(t *testing.T) {
+		assertGolden(t, "simple_service", "overlay_no_auth", WithOverlay(overlay, OverlayArraysAppend), WithNoAuth(true))
+	})
+	t.Run("auth_not_required", func(t *testing.T) {
+		assertGolden(t, "simple_service", "overlay_auth_not_required", WithOverlay(overlay, OverlayArraysAppend), WithAuthRequired(false))
+	})
+}
+
+func TestWriter_ParseCache(t *testing.T) {
+	opened := make(map[string]int)
+	openProtoFile = func(filename string) (*os.File, error) {
+		opened[filename]++
+		return os.Open(filename)
+	}
+	defer func() {
+		openProtoFile = os.Open
+	}()
+
+	writer := NewWriter("testdata/diamond.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := opened["testdata/diamond_base.proto"]; got != 1 {
+		t.Errorf("shared import parsed %d times, want 1", got)
+	}
+	for filename, count := range opened {
+		if count != 1 {
+			t.Errorf("%s parsed %d times, want 1", filename, count)
+		}
+	}
+}
+
+func TestWriter_ImportOnce(t *testing.T) {
+	writer := NewWriter("testdata/diamond.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"testdata/diamond.proto",
+		"testdata/diamond_left.proto",
+		"testdata/diamond_right.proto",
+		"testdata/diamond_base.proto",
+	}
+	for _, filename := range want {
+		if !writer.imported[filename] {
+			t.Errorf("%s wasn't walked", filename)
+		}
+	}
+	if len(writer.imported) != len(want) {
+		t.Errorf("walked %d files, want %d", len(writer.imported), len(want))
+	}
+}
+
+func TestWriter_OnlyPackagesFilteredRef(t *testing.T) {
+	writer := NewWriter("testdata/only_packages.proto", "api.example.com", "/twirp", WithOnlyPackages([]string{"extra"}))
+	err := writer.WalkFile()
+	if !errors.Is(err, ErrFilteredRef) {
+		t.Fatalf("got error %v, want ErrFilteredRef", err)
+	}
+	for _, name := range []string{"common_Money", "common_Receipt"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error doesn't mention %s: %s", name, err)
+		}
+	}
+}
+
+func TestWriter_Report(t *testing.T) {
+	writer := NewWriter("testdata/report.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	report := writer.Report()
+	if len(report.SkippedFiles) != 1 || report.SkippedFiles[0].Import != "testdata/missing.proto" {
+		t.Errorf("unexpected skipped files: %+v", report.SkippedFiles)
+	}
+	if len(report.UnresolvedRefs) != 1 || report.UnresolvedRefs[0].Ref != "#/definitions/missing_Response" {
+		t.Errorf("unexpected unresolved refs: %+v", report.UnresolvedRefs)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Fields["field"] != "status" {
+		t.Errorf("unexpected warnings: %+v", report.Warnings)
+	}
+}
+
+func TestWriter_Proto2Groups(t *testing.T) {
+	writer := NewWriter("testdata/proto2_groups.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := writer.Swagger.Definitions["groups_FindRequest"].Required; len(got) != 1 || got[0] != "query" {
+		t.Errorf("got required %v, want only the proto2 required field", got)
+	}
+	response := writer.Swagger.Definitions["groups_FindResponse"]
+	if _, ok := response.Properties["result"]; ok {
+		t.Error("the group field wasn't skipped")
+	}
+
+	warnings := writer.Report().Warnings
+	if len(warnings) != 1 || warnings[0].Message != "skipping group, groups are not supported" || warnings[0].Fields["field"] != "Result" {
+		t.Errorf("unexpected warnings: %+v", warnings)
+	}
+}
+
+func TestWriter_MalformedAnnotationsReport(t *testing.T) {
+	writer := NewWriter("testdata/malformed_annotations.proto", "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := writer.Report().Warnings
+	if len(warnings) != 3 {
+		t.Fatalf("got warnings %+v, want 3", warnings)
+	}
+	want := []struct {
+		message string
+		rpc     string
+	}{
+		{"ignoring malformed ratelimit annotation", "Get"},
+		{"ignoring malformed response annotation", "List"},
+		{"ignoring ratelimit annotation without a rate", "Delete"},
+	}
+	for k, warning := range warnings {
+		if warning.Message != want[k].message || warning.Fields["rpc"] != want[k].rpc || warning.Fields["file"] != "testdata/malformed_annotations.proto" {
+			t.Errorf("got warning %+v, want %q for rpc %s", warning, want[k].message, want[k].rpc)
+		}
+	}
+}
+
+func TestWriter_StrictUnresolvedRef(t *testing.T) {
+	writer := NewWriter("testdata/report.proto", "api.example.com", "/twirp", WithStrict(true))
+	err := writer.WalkFile()
+	if !errors.Is(err, ErrUnresolvedRef) {
+		t.Fatalf("got error %v, want ErrUnresolvedRef", err)
+	}
+	if !strings.Contains(err.Error(), "#/definitions/missing_Response") {
+		t.Errorf("error doesn't mention the ref: %s", err)
+	}
+}
+
+func TestWriter_StrictUnresolvedResponseRef(t *testing.T) {
+	missing := spec.RefSchema("#/definitions/missing_Error")
+	testCases := []struct {
+		name     string
+		modify   func(sw *Writer)
+		location string
+	}{
+		{"shared", func(sw *Writer) {
+			sw.Swagger.Responses = map[string]spec.Response{"404": *spec.NewResponse().WithSchema(missing)}
+		}, "response 404"},
+		{"default", func(sw *Writer) {
+			sw.Swagger.Paths.Paths["/twirp/simple.SimpleService/Get"].Post.Responses.Default = spec.NewResponse().WithSchema(missing)
+		}, "/twirp/simple.SimpleService/Get response default"},
+	}
+
+	for _, tc := range testCases {
+		writer := NewWriter("testdata/simple_service.proto", "api.example.com", "/twirp", WithStrict(true))
+		if err := writer.WalkFile(); err != nil {
+			t.Fatal(err)
+		}
+		tc.modify(writer)
+
+		err := writer.checkUnresolvedRefs()
+		if !errors.Is(err, ErrUnresolvedRef) {
+			t.Errorf("%s: got error %v, want ErrUnresolvedRef", tc.name, err)
+			continue
+		}
+		refs := writer.Report().UnresolvedRefs
+		if len(refs) != 1 || refs[0].Location != tc.location {
+			t.Errorf("%s: got unresolved refs %+v, want one in %s", tc.name, refs, tc.location)
+		}
+	}
+}
+
+func TestWriter_DefinitionsOnlyWithoutServices(t *testing.T) {
+	writer := NewWriter("testdata/imported_types_dep.proto", "api.example.com", "/twirp", WithDefinitionsOnly(true))
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := writer.Swagger.Definitions["dep_Shared"]; !ok {
+		t.Errorf("missing definition dep_Shared, got %v", writer.Swagger.Definitions)
+	}
+}
+
+func TestWriter_VersionInPath(t *testing.T) {
+	testCases := []struct {
+		version string
+		want    string
+		err     error
+	}{
+		{"1.2.3", "/v1/twirp/simple.SimpleService/Get", nil},
+		{"v2.0", "/v2/twirp/simple.SimpleService/Get", nil},
+		{"latest", "", ErrInvalidVersion},
+		{"", "", ErrInvalidVersion},
+	}
+
+	for _, tc := range testCases {
+		writer := NewWriter("testdata/simple_service.proto", "api.example.com", "/twirp", WithVersion(tc.version), WithVersionInPath(true))
+		err := writer.WalkFile()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: got error %v, want %v", tc.version, err, tc.err)
+			continue
+		}
+		if tc.err != nil {
+			continue
+		}
+		if _, ok := writer.Swagger.Paths.Paths[tc.want]; !ok {
+			t.Errorf("%q: got paths %v, want %s", tc.version, sortedPaths(writer.Swagger.Paths), tc.want)
+		}
+	}
+}
+
+func TestWriter_APIPrefix(t *testing.T) {
+	testCases := []struct {
+		host   string
+		prefix string
+		opts   []WriterOption
+		want   string
+	}{
+		{"api.example.com", "/v1", nil, "https://api.example.com/v1/twirp/simple.SimpleService/Get"},
+		{"api.example.com", "v1/", nil, "https://api.example.com/v1/twirp/simple.SimpleService/Get"},
+		{"https://api.example.com/gateway", "/v1", nil, "https://api.example.com/gateway/v1/twirp/simple.SimpleService/Get"},
+		{"api.example.com", "/api", []WriterOption{WithVersion("2.0.0"), WithVersionInPath(true)}, "https://api.example.com/api/v2/twirp/simple.SimpleService/Get"},
+	}
+
+	for _, tc := range testCases {
+		opts := append([]WriterOption{WithAPIPrefix(tc.prefix)}, tc.opts...)
+		writer := NewWriter("testdata/simple_service.proto", tc.host, "/twirp", opts...)
+		if err := writer.WalkFile(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the "Try it out" URL of swagger-ui
+		paths := sortedPaths(writer.Swagger.Paths)
+		if len(paths) != 2 {
+			t.Fatalf("%s: got paths %v, want Get and List", tc.prefix, paths)
+		}
+		scheme := writer.Schemes[len(writer.Schemes)-1]
+		if got := scheme + "://" + writer.Host + writer.BasePath + paths[0]; got != tc.want {
+			t.Errorf("%s %s: got %s, want %s", tc.host, tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestWriter_AsyncAPI(t *testing.T) {
+	testCases := []struct {
+		name              string
+		opts              []WriterOption
+		request, response string
+	}{
+		{"default", nil, "dep_Request", "renamed_Response"},
+		{"renamed", []WriterOption{WithRenames(map[string]string{"dep.Request": "GetRequest", "renamed.Response": "GetResponse"})}, "GetRequest", "GetResponse"},
+	}
+
+	for _, tc := range testCases {
+		writer := NewWriter("testdata/renamed_types.proto", "api.example.com", "/twirp", tc.opts...)
+		if err := writer.WalkFile(); err != nil {
+			t.Fatal(err)
+		}
+		if !writer.HasStreams() {
+			t.Fatalf("%s: no streaming rpcs", tc.name)
+		}
+
+		var doc asyncapi.Document
+		if err := json.Unmarshal(writer.GetAsyncAPI(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		channel, ok := doc.Channels["/twirp/renamed.RenameService/Watch"]
+		if !ok {
+			t.Fatalf("%s: missing Watch channel, got %v", tc.name, doc.Channels)
+		}
+		for _, ref := range []struct{ got, want string }{
+			{channel.Publish.Message.Payload.Ref, tc.request},
+			{channel.Subscribe.Message.Payload.Ref, tc.response},
+		} {
+			if ref.got != "#/components/schemas/"+ref.want {
+				t.Errorf("%s: got payload %s, want %s", tc.name, ref.got, ref.want)
+			}
+			if _, ok := doc.Components.Schemas[ref.want]; !ok {
+				t.Errorf("%s: payload %s has no schema", tc.name, ref.want)
+			}
+		}
+	}
+}
+
+func TestWriter_Renames(t *testing.T) {
+	responses := map[string]spec.Response{
+		"404": *spec.NewResponse().WithDescription("Not found").WithSchema(spec.RefSchema("#/definitions/renamed_Response")),
+	}
+	writer := NewWriter("testdata/renamed_types.proto", "api.example.com", "/twirp", WithResponses(responses), WithStrict(true), WithRenames(map[string]string{"renamed.Response": "GetResponse"}))
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := writer.Swagger.Responses["404"].Schema.Ref.String(); got != "#/definitions/GetResponse" {
+		t.Errorf("got shared response ref %s, want #/definitions/GetResponse", got)
+	}
+
+	conflicts := []map[string]string{
+		{"dep.Request": "Shared", "renamed.Response": "Shared"},
+		{"dep.Request": "dep_Shared"},
+	}
+	for _, renames := range conflicts {
+		writer := NewWriter("testdata/renamed_types.proto", "api.example.com", "/twirp", WithRenames(renames))
+		if err := writer.WalkFile(); !errors.Is(err, ErrRenameConflict) {
+			t.Errorf("%v: got error %v, want ErrRenameConflict", renames, err)
+		}
+	}
+}
+
+func TestWriter_Reset(t *testing.T) {
+	writer := NewWriter("testdata/diamond.proto", "api.example.com", "/twirp", WithVersion("1.0.0"))
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer.Reset("testdata/map_fields.proto", "maps.example.com")
+	if err := writer.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name := range writer.Swagger.Definitions {
+		if !strings.HasPrefix(name, "maps_") {
+			t.Errorf("definition %s from the first file wasn't cleared", name)
+		}
+	}
+	for name := range writer.Swagger.Paths.Paths {
+		if !strings.HasPrefix(name, "/twirp/maps.") {
+			t.Errorf("path %s from the first file wasn't cleared", name)
+		}
+	}
+	if got := writer.Services(); len(got) != 1 || got[0] != "MapService" {
+		t.Errorf("got services %v, want [MapService]", got)
+	}
+	if writer.Host != "maps.example.com" || writer.Info.Version != "1.0.0" {
+		t.Errorf("got host %q and version %q, want the new host and the kept version", writer.Host, writer.Info.Version)
+	}
+
+	// the result is the same as with a new writer
+	want := NewWriter("testdata/map_fields.proto", "maps.example.com", "/twirp", WithVersion("1.0.0"))
+	if err := want.WalkFile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := writer.Get(); string(got) != string(want.Get()) {
+		t.Errorf("reset writer output differs from a new writer")
+	}
+}
+
+func TestWriter_SharedParseCache(t *testing.T) {
+	opened := make(map[string]int)
+	openProtoFile = func(filename string) (*os.File, error) {
+		opened[filename]++
+		return os.Open(filename)
+	}
+	defer func() {
+		openProtoFile = os.Open
+	}()
+
+	cache := NewParseCache()
+	for _, filename := range []string{"testdata/diamond.proto", "testdata/diamond.proto", "testdata/diamond_left.proto"} {
+		writer := NewWriter(filename, "api.example.com", "/twirp", WithParseCache(cache))
+		if err := writer.WalkFile(); err != nil && !errors.Is(err, ErrNoServiceDefinition) {
+			t.Fatal(err)
+		}
+	}
+
+	for filename, count := range opened {
+		if count != 1 {
+			t.Errorf("%s parsed %d times, want 1", filename, count)
+		}
+	}
+	if len(opened) != 4 {
+		t.Errorf("parsed %d files, want 4", len(opened))
+	}
+}
+
+// assertGolden generates testdata/<name>.proto and compares the output
+// with testdata/<golden>.golden.json. Run `go test -update` to regenerate.
+func assertGolden(t *testing.T, name, golden string, opts ...WriterOption) {
+	t.Helper()
+
+	writer := NewWriter("testdata/"+name+".proto", "api.example.com", "/twirp", opts...)
+	if err := writer.WalkFile(); err != nil && !errors.Is(err, ErrNoServiceDefinition) {
+		t.Fatalf("WalkFile: %s", err)
+	}
+	got := writer.Get()
+
+	golden = "testdata/" + golden + ".golden.json"
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%s, run go test -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s", golden, diffLines(string(want), string(got)))
+	}
+}
+
+// diffLines returns the first differing lines between want and got.
+func diffLines(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for k := 0; k < len(wantLines) || k < len(gotLines); k++ {
+		var w, g string
+		if k < len(wantLines) {
+			w = wantLines[k]
+		}
+		if k < len(gotLines) {
+			g = gotLines[k]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", k+1, w, g)
+		}
+	}
+	return ""
+}
+
+func BenchmarkWriterWalkFile(b *testing.B) {
+	filename := writeLargeProto(b, b.TempDir(), 100, 20)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writer := NewWriter(filename, "api.example.com", "/twirp")
+		if err := writer.WalkFile(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriterGet(b *testing.B) {
+	filename := writeLargeProto(b, b.TempDir(), 100, 20)
+	writer := NewWriter(filename, "api.example.com", "/twirp")
+	if err := writer.WalkFile(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writer.Get()
+	}
+}
+
+func BenchmarkWriterLargeImports(b *testing.B) {
+	dir := b.TempDir()
+
+	var main strings.Builder
+	main.WriteString("syntax = \"proto3\";\n\npackage bench;\n\n")
+	for k := 0; k < 20; k++ {
+		name := fmt.Sprintf("dep%d", k)
+		depFilename := filepath.Join(dir, name+".proto")
+		writeFile(b, depFilename, largeProto(name, 10, 10, false))
+		// imports are resolved relative to the working directory
+		fmt.Fprintf(&main, "import \"%s\";\n", depFilename)
+	}
+	main.WriteString("\nmessage Request {\n")
+	for k := 0; k < 20; k++ {
+		fmt.Fprintf(&main, "  dep%d.Message0 dep%d = %d;\n", k, k, k+1)
+	}
+	main.WriteString("}\n\nservice Bench {\n  rpc Call(Request) returns (Request);\n}\n")
+
+	filename := filepath.Join(dir, "main.proto")
+	writeFile(b, filename, main.String())
+
+	for _, parallelImports := range []int{1, 4} {
+		b.Run(fmt.Sprintf("parallel_imports=%d", parallelImports), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				writer := NewWriter(filename, "api.example.com", "/twirp", WithParallelImports(parallelImports))
+				if err := writer.WalkFile(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWriterSharedImports generates 20 files which all import the
+// same 10 files, with a parse cache for each writer or shared between
+// the writers, like the jobs of a manifest.
+func BenchmarkWriterSharedImports(b *testing.B) {
+	dir := b.TempDir()
+
+	var imports strings.Builder
+	for k := 0; k < 10; k++ {
+		name := fmt.Sprintf("common%d", k)
+		depFilename := filepath.Join(dir, name+".proto")
+		writeFile(b, depFilename, largeProto(name, 20, 10, false))
+		fmt.Fprintf(&imports, "import \"%s\";\n", depFilename)
+	}
+
+	filenames := []string{}
+	for k := 0; k < 20; k++ {
+		filename := filepath.Join(dir, fmt.Sprintf("service%d.proto", k))
+		body := fmt.Sprintf("syntax = \"proto3\";\n\npackage service%d;\n\n%s\n", k, imports.String()) +
+			"message Request {\n  common0.Message0 common = 1;\n}\n\n" +
+			"service Service {\n  rpc Call(Request) returns (Request);\n}\n"
+		writeFile(b, filename, body)
+		filenames = append(filenames, filename)
+	}
+
+	run := func(b *testing.B, newCache func() *ParseCache) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cache := NewParseCache()
+			for _, filename := range filenames {
+				if newCache != nil {
+					cache = newCache()
+				}
+				writer := NewWriter(filename, "api.example.com", "/twirp", WithParseCache(cache))
+				if err := writer.WalkFile(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("per_writer", func(b *testing.B) {
+		run(b, NewParseCache)
+	})
+	b.Run("shared", func(b *testing.B) {
+		run(b, nil)
+	})
+}
+
+// writeLargeProto writes a proto file with a service and the given
+// number of messages and fields per message into dir.
+func writeLargeProto(b *testing.B, dir string, messages, fields int) string {
+	b.Helper()
+	filename := filepath.Join(dir, "large.proto")
+	writeFile(b, filename, largeProto("bench", messages, fields, true))
+	return filename
+}
+
+func largeProto(pkg string, messages, fields int, withService bool) string {
+	types := []string{"string", "int32", "int64", "bool", "double", "bytes"}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "syntax = \"proto3\";\n\npackage %s;\n\n", pkg)
+	for m := 0; m < messages; m++ {
+		fmt.Fprintf(&sb, "// Message%d is a generated message.\nmessage Message%d {\n", m, m)
+		for f := 0; f < fields; f++ {
+			fmt.Fprintf(&sb, "  // Field %d of message %d.\n  %s field%d = %d;\n", f, m, types[f%len(types)], f, f+1)
+		}
+		sb.WriteString("}\n\n")
+	}
+	if withService {
+		sb.WriteString("service Bench {\n")
+		for m := 0; m < messages; m++ {
+			fmt.Fprintf(&sb, "  rpc Call%d(Message%d) returns (Message%d);\n", m, m, m)
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+func writeFile(b *testing.B, filename, contents string) {
+	b.Helper()
+	if err := ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+		b.Fatal(err)
+	}
+}